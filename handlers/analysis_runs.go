@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/middleware"
+	"PennieAI/models"
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+type ReanalyzeRequest struct {
+	Model             string `json:"model"`
+	PromptVersion     string `json:"prompt_version"`
+	WindowSize        int    `json:"window_size"`
+	OverlapSize       *int   `json:"overlap_size"` // Omitted keeps the default share of the window, 0 means no overlap
+	TokenBudget       int    `json:"token_budget"` // Size windows by estimated prompt tokens instead of lines
+	SnapTolerance     int    `json:"snap_tolerance"`
+	Parallel          bool   `json:"parallel"`
+	Concurrency       int    `json:"concurrency"`
+	Segmenter         string `json:"segmenter"` // "ai" (default) or "heuristic"
+	BoundaryHints     bool   `json:"boundary_hints"`
+	HeuristicFallback bool   `json:"heuristic_fallback"` // Use the heuristic segmenter if the AI service fails
+	Extract           bool   `json:"extract"`            // Run the AI extractors on documents whose lines changed
+	Promote           bool   `json:"promote"`            // Make the new run current right away
+}
+
+// ReanalyzeUnprocessedDocument runs segmentation again on a stored upload and stores the result as a new run
+func ReanalyzeUnprocessedDocument(c *gin.Context) {
+	doctor, ok := middleware.GetAuthenticatedUser(c)
+	if !ok {
+		fmt.Println("ERROR: GetAuthenticatedUser failed - check route middleware configuration")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	unprocessedDocumentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid unprocessed document ID format",
+		})
+		return
+	}
+
+	// An empty body re-runs with the default settings
+	var req ReanalyzeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	settings := services.AnalysisSettings{
+		Parallel:          req.Parallel,
+		Concurrency:       req.Concurrency,
+		Model:             req.Model,
+		PromptVersion:     req.PromptVersion,
+		WindowSize:        req.WindowSize,
+		OverlapSize:       req.OverlapSize,
+		TokenBudget:       req.TokenBudget,
+		SnapTolerance:     req.SnapTolerance,
+		Segmenter:         req.Segmenter,
+		BoundaryHints:     req.BoundaryHints,
+		HeuristicFallback: req.HeuristicFallback,
+		Extract:           req.Extract,
+	}
+	if _, err := settings.Resolved(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid analysis settings",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	aiService := services.NewAIService()
+	result, err := services.ReanalyzeDocument(c.Request.Context(), unprocessedDocumentID, doctor.ID, settings, req.Promote, aiService)
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to re-analyze document", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result,
+		"message": "Document re-analyzed successfully",
+	})
+}
+
+func GetAnalysisRuns(c *gin.Context) {
+	unprocessedDocument, ok := findOwnedUnprocessedDocument(c)
+	if !ok {
+		return
+	}
+
+	runs, err := repository.GetAnalysisRuns(unprocessedDocument.ID)
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to fetch analysis runs", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  runs,
+		"count": len(runs),
+	})
+}
+
+// CompareAnalysisRuns diffs a run against ?base=<runId>, or against the current run if base is omitted
+func CompareAnalysisRuns(c *gin.Context) {
+	unprocessedDocument, ok := findOwnedUnprocessedDocument(c)
+	if !ok {
+		return
+	}
+
+	runID, err := strconv.ParseInt(c.Param("runId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID format"})
+		return
+	}
+
+	otherRun, err := repository.FindAnalysisRun(unprocessedDocument.ID, runID)
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to fetch analysis run", err)
+		return
+	}
+
+	var baseRun models.AnalysisRun
+	if baseParam := c.Query("base"); baseParam != "" {
+		baseRunID, err := strconv.ParseInt(baseParam, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid base run ID format"})
+			return
+		}
+		baseRun, err = repository.FindAnalysisRun(unprocessedDocument.ID, baseRunID)
+	} else {
+		baseRun, err = repository.FindCurrentAnalysisRun(unprocessedDocument.ID)
+	}
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to fetch base analysis run", err)
+		return
+	}
+
+	baseDocuments, err := repository.GetAnalyzedDocumentsByRunID(baseRun.ID)
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to fetch documents", err)
+		return
+	}
+	otherDocuments, err := repository.GetAnalyzedDocumentsByRunID(otherRun.ID)
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to fetch documents", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": services.CompareRuns(baseRun, baseDocuments, otherRun, otherDocuments),
+	})
+}
+
+func PromoteAnalysisRun(c *gin.Context) {
+	unprocessedDocument, ok := findOwnedUnprocessedDocument(c)
+	if !ok {
+		return
+	}
+
+	runID, err := strconv.ParseInt(c.Param("runId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID format"})
+		return
+	}
+
+	if err := repository.PromoteAnalysisRun(unprocessedDocument.ID, runID); err != nil {
+		respondAnalysisRunError(c, "Failed to promote analysis run", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Analysis run promoted to current",
+	})
+}
+
+// findOwnedUnprocessedDocument loads the :id upload for the signed-in doctor. It writes the error response itself.
+func findOwnedUnprocessedDocument(c *gin.Context) (models.UnprocessedDocument, bool) {
+	doctor, ok := middleware.GetAuthenticatedUser(c)
+	if !ok {
+		fmt.Println("ERROR: GetAuthenticatedUser failed - check route middleware configuration")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return models.UnprocessedDocument{}, false
+	}
+
+	unprocessedDocumentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid unprocessed document ID format",
+		})
+		return models.UnprocessedDocument{}, false
+	}
+
+	unprocessedDocument, err := repository.FindUnprocessedDocumentForDoctor(unprocessedDocumentID, doctor.ID)
+	if err != nil {
+		respondAnalysisRunError(c, "Failed to fetch unprocessed document", err)
+		return models.UnprocessedDocument{}, false
+	}
+
+	return unprocessedDocument, true
+}
+
+func respondAnalysisRunError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, repository.ErrUnprocessedDocumentNotFound) || errors.Is(err, repository.ErrAnalysisRunNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -1,46 +1,204 @@
 package handlers
 
 import (
+	"PennieAI/middleware"
 	"PennieAI/models"
+	"PennieAI/repository"
 	"PennieAI/services"
+	"PennieAI/utils"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AnalyzeResponse struct {
-	Message   string                    `json:"message"`
-	Count     int                       `json:"count"`
-	Patient   *models.Patient           `json:"patient"`
-	Documents []models.AnalyzedDocument `json:"documents"`
+	Message               string                             `json:"message"`
+	Count                 int                                `json:"count"`
+	UnprocessedDocumentID int64                              `json:"unprocessedDocumentId"`
+	Patients              []*models.Patient                  `json:"patients"` // Primary patient first
+	Documents             []models.AnalyzedDocument          `json:"documents"`
+	Gaps                  []services.BoundaryGap             `json:"gaps"`             // Spaces between documents larger than expected
+	ValidationErrors      []services.DocumentValidationError `json:"validationErrors"` // Documents skipped because the model's output was unusable
+	Coverage              models.CoverageReport              `json:"coverage"`
+	ExtractionErrors      []services.ExtractionError         `json:"extractionErrors"` // Document extractors that failed, only with extract=true
+	ResumedWindows        int                                `json:"resumedWindows"`
 }
 
 func AnalyzeUnprocessedDocument(c *gin.Context) {
-	file, err := c.FormFile("document")
+	request, ok := bindAnalysisRequest(c)
+	if !ok {
+		return
+	}
+
+	aiService := services.NewAIService()
+	result, err := services.RunAnalysis(c.Request.Context(), request, aiService, nil)
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Failed to get document from request",
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrPatientNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to analyze document",
 			"message": err.Error(),
 		})
 		return
 	}
 
-	aiService := services.NewAIService()
-	patient, analyzedDocuments, err := services.AnalyzeDocument(file, aiService)
+	c.JSON(http.StatusOK, AnalyzeResponse{
+		Message:               "Document analyzed successfully",
+		Count:                 len(result.Documents),
+		UnprocessedDocumentID: result.UnprocessedDocumentID,
+		Patients:              result.Patients,
+		Documents:             result.Documents,
+		Gaps:                  result.Gaps,
+		ValidationErrors:      result.ValidationErrors,
+		Coverage:              result.Coverage,
+		ExtractionErrors:      result.ExtractionErrors,
+		ResumedWindows:        result.ResumedWindows,
+	})
+}
+
+// EnqueueDocumentAnalysis queues the upload for a background worker and returns the job to poll
+func EnqueueDocumentAnalysis(c *gin.Context) {
+	request, ok := bindAnalysisRequest(c)
+	if !ok {
+		return
+	}
 
+	job, err := services.EnqueueAnalysis(c.Request.Context(), request)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to analyze document",
+			"error":   "Failed to queue document analysis",
 			"message": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, AnalyzeResponse{
-		Message:   "Document analyzed successfully",
-		Count:     len(analyzedDocuments),
-		Patient:   patient,
-		Documents: analyzedDocuments,
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Document analysis queued",
+		"jobId":   job.ID,
+		"status":  job.Status,
 	})
 }
+
+// bindAnalysisRequest reads the multipart upload and optional form settings. It writes the error response itself.
+func bindAnalysisRequest(c *gin.Context) (services.AnalysisRequest, bool) {
+	doctor, ok := middleware.GetAuthenticatedUser(c)
+	if !ok {
+		fmt.Println("ERROR: GetAuthenticatedUser failed - check route middleware configuration")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return services.AnalysisRequest{}, false
+	}
+
+	file, err := c.FormFile("document")
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to get document from request",
+			"message": err.Error(),
+		})
+		return services.AnalysisRequest{}, false
+	}
+
+	// Optional: link the extracted documents to an existing patient instead of creating a new one
+	var existingPatientID int
+	if patientIDParam := c.PostForm("patient_id"); patientIDParam != "" {
+		existingPatientID, err = strconv.Atoi(patientIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid patient ID format",
+			})
+			return services.AnalysisRequest{}, false
+		}
+	}
+
+	// Optional: query windows concurrently instead of one after another
+	var settings services.AnalysisSettings
+	if parallelParam := c.PostForm("parallel"); parallelParam != "" {
+		settings.Parallel, err = strconv.ParseBool(parallelParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid parallel flag, expected true or false",
+			})
+			return services.AnalysisRequest{}, false
+		}
+	}
+	if concurrencyParam := c.PostForm("concurrency"); concurrencyParam != "" {
+		settings.Concurrency, err = strconv.Atoi(concurrencyParam)
+		if err != nil || settings.Concurrency < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid concurrency, expected a positive integer",
+			})
+			return services.AnalysisRequest{}, false
+		}
+		settings.Concurrency = min(settings.Concurrency, services.MaxWindowConcurrency)
+	}
+
+	// Optional: size windows by estimated prompt tokens instead of a fixed line count
+	if tokenBudgetParam := c.PostForm("token_budget"); tokenBudgetParam != "" {
+		settings.TokenBudget, err = strconv.Atoi(tokenBudgetParam)
+		if err != nil || settings.TokenBudget < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid token budget, expected a positive integer",
+			})
+			return services.AnalysisRequest{}, false
+		}
+	}
+
+	// Optional: let window edges move this many lines to land on a header or blank line
+	if snapToleranceParam := c.PostForm("snap_tolerance"); snapToleranceParam != "" {
+		settings.SnapTolerance, err = strconv.Atoi(snapToleranceParam)
+		if err != nil || settings.SnapTolerance < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid snap tolerance, expected a non-negative integer",
+			})
+			return services.AnalysisRequest{}, false
+		}
+	}
+
+	// Optional: "heuristic" segments without calling the AI service at all.
+	// extract=true also runs the AI extractors (summary, medications, ...) on every document.
+	settings.Segmenter = c.PostForm("segmenter")
+	for param, flag := range map[string]*bool{
+		"boundary_hints":     &settings.BoundaryHints,
+		"heuristic_fallback": &settings.HeuristicFallback,
+		"extract":            &settings.Extract,
+	} {
+		if value := c.PostForm(param); value != "" {
+			*flag, err = strconv.ParseBool(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("Invalid %s flag, expected true or false", param),
+				})
+				return services.AnalysisRequest{}, false
+			}
+		}
+	}
+	if _, err := settings.Resolved(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid analysis settings",
+			"message": err.Error(),
+		})
+		return services.AnalysisRequest{}, false
+	}
+
+	fileLines, err := utils.GetFileLines(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read document",
+			"message": err.Error(),
+		})
+		return services.AnalysisRequest{}, false
+	}
+
+	return services.AnalysisRequest{
+		FileLines: fileLines,
+		DoctorID:  doctor.ID,
+		PatientID: existingPatientID,
+		Settings:  settings,
+	}, true
+}
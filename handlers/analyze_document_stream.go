@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/services"
+)
+
+/*
+AnalyzeUnprocessedDocumentStream runs the same analysis as AnalyzeUnprocessedDocument but reports
+progress as Server-Sent Events instead of a single JSON body:
+  - "window":   one per sliding window, with its line range, newly found documents and the merged patients
+  - "document": one per saved document once its extractors are done, with what ran, what was reused and what failed
+  - "summary":  once the run has been saved, with the same payload as the non-streaming endpoint
+  - "error":    if analysis or saving fails, after which the stream ends
+*/
+func AnalyzeUnprocessedDocumentStream(c *gin.Context) {
+	request, ok := bindAnalysisRequest(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx-style proxies from buffering events
+	c.Status(http.StatusOK)
+
+	aiService := services.NewAIService()
+	result, err := services.RunAnalysis(c.Request.Context(), request, aiService, &services.AnalyzeOptions{
+		OnWindowComplete: func(progress services.WindowProgress) {
+			c.SSEvent("window", progress)
+			c.Writer.Flush()
+		},
+		OnDocumentExtracted: func(extraction services.DocumentExtraction) {
+			c.SSEvent("document", extraction)
+			c.Writer.Flush()
+		},
+	})
+
+	if err != nil {
+		c.SSEvent("error", gin.H{
+			"error":   "Failed to analyze document",
+			"message": err.Error(),
+		})
+		c.Writer.Flush()
+		return
+	}
+
+	c.SSEvent("summary", AnalyzeResponse{
+		Message:               "Document analyzed successfully",
+		Count:                 len(result.Documents),
+		UnprocessedDocumentID: result.UnprocessedDocumentID,
+		Patients:              result.Patients,
+		Documents:             result.Documents,
+		Gaps:                  result.Gaps,
+		ValidationErrors:      result.ValidationErrors,
+		Coverage:              result.Coverage,
+		ExtractionErrors:      result.ExtractionErrors,
+		ResumedWindows:        result.ResumedWindows,
+	})
+	c.Writer.Flush()
+}
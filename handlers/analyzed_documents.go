@@ -1,21 +1,40 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"PennieAI/config"
 	"PennieAI/models"
+	"PennieAI/repository"
 )
 
+// GetAllAnalyzedDocuments lists segmented documents. ?type= filters by document type and takes
+// a comma separated list or can be repeated, e.g. ?type=lab_report,imaging_report
 func GetAllAnalyzedDocuments(c *gin.Context) {
-	db := config.GetDB()
-
-	var documents []models.AnalyzedDocument
-	err := db.Select(&documents, "SELECT * FROM documents ORDER BY created_at DESC")
+	var documentTypes []string
+	for _, param := range c.QueryArray("type") {
+		for _, documentType := range strings.Split(param, ",") {
+			documentType = strings.TrimSpace(documentType)
+			if documentType == "" {
+				continue
+			}
+			if !models.IsDocumentType(documentType) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid document type",
+					"message": fmt.Sprintf("%q is not one of %s", documentType, strings.Join(models.DocumentTypes, ", ")),
+				})
+				return
+			}
+			documentTypes = append(documentTypes, documentType)
+		}
+	}
 
+	documents, err := repository.GetAnalyzedDocuments(documentTypes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to fetch documents",
@@ -44,7 +63,7 @@ func GetDocumentByID(c *gin.Context) {
 	}
 
 	var document models.AnalyzedDocument
-	err = db.Get(&document, "SELECT * FROM documents WHERE id = $1", id)
+	err = db.Get(&document, "SELECT * FROM analyzed_documents WHERE id = $1", id)
 
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -70,6 +89,14 @@ func CreateDocument(c *gin.Context) {
 		return
 	}
 
+	if !models.IsDocumentType(req.DocumentType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid document type",
+			"message": fmt.Sprintf("document_type must be one of %s", strings.Join(models.DocumentTypes, ", ")),
+		})
+		return
+	}
+
 	var document models.AnalyzedDocument
 	query := `
 		INSERT INTO documents (title, content, document_type, veterinarian_id, created_at, updated_at) 
@@ -104,7 +131,7 @@ func DeleteDocument(c *gin.Context) {
 		return
 	}
 
-	result, err := db.Exec("DELETE FROM documents WHERE id = $1", id)
+	result, err := db.Exec("DELETE FROM analyzed_documents WHERE id = $1", id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to delete document",
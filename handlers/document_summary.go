@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/middleware"
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+/*
+SummarizeDocument (re)generates a document's AI summary. A summary written for the document's
+current boundaries is returned as is unless ?force=true; one written before the boundaries
+changed is always regenerated. ?model= overrides OPENAI_MODEL_VERSION.
+*/
+func SummarizeDocument(c *gin.Context) {
+	doctor, ok := middleware.GetAuthenticatedUser(c)
+	if !ok {
+		fmt.Println("ERROR: GetAuthenticatedUser failed - check route middleware configuration")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	documentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid document ID format",
+		})
+		return
+	}
+
+	document, err := repository.FindAnalyzedDocumentForDoctor(documentID, doctor.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAnalyzedDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch document",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if document.SummaryIsCurrent() && c.Query("force") != "true" {
+		c.JSON(http.StatusOK, gin.H{
+			"data":        document,
+			"regenerated": false,
+		})
+		return
+	}
+
+	model := c.Query("model")
+	if model == "" {
+		model = services.GetModelVersion()
+	}
+
+	aiService := services.NewAIService()
+	if err := services.SummarizeDocument(c.Request.Context(), aiService, &document, model); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to summarize document",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        document,
+		"regenerated": true,
+	})
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"PennieAI/models"
 	"PennieAI/repository"
+	"PennieAI/services"
 )
 
 func GetPatients(c *gin.Context) {
@@ -23,6 +25,16 @@ func GetPatients(c *gin.Context) {
 		return
 	}
 
+	// Allergies come with every patient so they can't be missed
+	patientRefs := make([]*models.Patient, len(patients))
+	for i := range patients {
+		patientRefs[i] = &patients[i]
+	}
+	if err := services.AttachAllergies(patientRefs); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch patient allergies"})
+		return
+	}
+
 	c.JSON(200, patients)
 
 }
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/middleware"
+	"PennieAI/models"
+	"PennieAI/services"
+)
+
+func GetJob(c *gin.Context) {
+	job, ok := findOwnedJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": job,
+	})
+}
+
+// RetryJob re-queues a failed job; windows finished before the failure are not queried again
+func RetryJob(c *gin.Context) {
+	job, ok := findOwnedJob(c)
+	if !ok {
+		return
+	}
+
+	if err := services.RetryAnalysisJob(c.Request.Context(), job); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrJobNotRetryable) {
+			status = http.StatusConflict
+		} else if errors.Is(err, services.ErrJobNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to retry job",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Document analysis re-queued",
+		"jobId":   job.ID,
+		"status":  job.Status,
+	})
+}
+
+// findOwnedJob loads the :id job for the signed-in doctor. It writes the error response itself.
+func findOwnedJob(c *gin.Context) (*models.AnalysisJob, bool) {
+	doctor, ok := middleware.GetAuthenticatedUser(c)
+	if !ok {
+		fmt.Println("ERROR: GetAuthenticatedUser failed - check route middleware configuration")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return nil, false
+	}
+
+	job, err := services.GetAnalysisJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch job",
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+
+	// Jobs are only visible to the doctor who queued them
+	if job.DoctorID != doctor.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, false
+	}
+
+	return job, true
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+/*
+GetPatientAllergies returns a patient's allergy and adverse reaction registry, most severe
+first. data has one entry per allergen (its most severe record); records has every document's
+record with the lines it came from.
+*/
+func GetPatientAllergies(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	allergies, err := repository.GetPatientAllergies(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient allergies",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	merged := services.MergeAllergies(allergies)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    merged,
+		"count":   len(merged),
+		"records": allergies,
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/config"
+	"PennieAI/middleware"
+	"PennieAI/models"
+	"PennieAI/repository"
+)
+
+/*
+GetPatientFieldSources shows where each of a patient's field values came from: the document,
+line, window and inference. Sources are grouped by field (the JSON field name, e.g.
+"possibleBreed") and ordered oldest first, so the last entry of a single-valued field is the
+value the patient has now. ?field= limits the response to one field.
+*/
+func GetPatientFieldSources(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	sources, err := repository.GetPatientFieldSources(patient.ID, c.Query("field"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient field sources",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	byField := map[string][]models.PatientFieldSource{}
+	for _, source := range sources {
+		byField[source.Field] = append(byField[source.Field], source)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  byField,
+		"count": len(sources),
+	})
+}
+
+// findOwnedPatient loads the :id patient for the signed-in doctor. It writes the error response itself.
+func findOwnedPatient(c *gin.Context) (models.Patient, bool) {
+	doctor, ok := middleware.GetAuthenticatedUser(c)
+	if !ok {
+		fmt.Println("ERROR: GetAuthenticatedUser failed - check route middleware configuration")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return models.Patient{}, false
+	}
+
+	patientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid patient ID format",
+		})
+		return models.Patient{}, false
+	}
+
+	patient, err := repository.FindPatientForDoctor(config.GetDB(), patientID, doctor.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return models.Patient{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient",
+			"message": err.Error(),
+		})
+		return models.Patient{}, false
+	}
+
+	return patient, true
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/repository"
+)
+
+/*
+GetPatientLabResults lists the lab results found in a patient's documents, newest first, each
+with its reference range, flag and source document. ?abnormal=true keeps only values that were
+high, low or otherwise out of range; ?analyte= limits the list to one analyte.
+*/
+func GetPatientLabResults(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	abnormalOnly := false
+	if param := c.Query("abnormal"); param != "" {
+		parsed, err := strconv.ParseBool(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "abnormal must be true or false",
+			})
+			return
+		}
+		abnormalOnly = parsed
+	}
+
+	results, err := repository.GetPatientLabResults(patient.ID, abnormalOnly, c.Query("analyte"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient lab results",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	abnormalCount := 0
+	for _, result := range results {
+		if result.IsAbnormal() {
+			abnormalCount++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":          results,
+		"count":         len(results),
+		"abnormalCount": abnormalCount,
+	})
+}
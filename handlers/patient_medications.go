@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+/*
+GetPatientMedications lists the medications found in a patient's documents, split into the ones
+the patient is currently on and past ones (completed, discontinued, past their stop date, or of
+unknown status and months old, see models.Medication.IsCurrent).
+Each medication points at the document and lines it was read from, and lists under
+allergyConflicts the patient's recorded allergens it matches.
+*/
+func GetPatientMedications(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	medications, err := repository.GetPatientMedications(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient medications",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	allergies, err := repository.GetPatientAllergies(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient allergies",
+			"message": err.Error(),
+		})
+		return
+	}
+	allergies = services.MergeAllergies(allergies)
+
+	today := time.Now()
+	conflictCount := 0
+	current := []models.Medication{}
+	past := []models.Medication{}
+	for _, medication := range medications {
+		medication.AllergyConflicts = services.AllergyConflicts(medication, allergies)
+		if len(medication.AllergyConflicts) > 0 {
+			conflictCount++
+		}
+
+		if medication.IsCurrent(today) {
+			current = append(current, medication)
+		} else {
+			past = append(past, medication)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"current": current,
+			"past":    past,
+		},
+		"count":                len(medications),
+		"allergies":            allergies,
+		"allergyConflictCount": conflictCount,
+	})
+}
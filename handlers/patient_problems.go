@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+/*
+GetPatientProblems returns a patient's problem list: the diagnoses its documents record, coded
+against the problem vocabulary, each with its onset date, whether it is active or resolved and
+the documents that support it. ?status=active or ?status=resolved limits the list.
+*/
+func GetPatientProblems(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != models.ProblemStatusActive && status != models.ProblemStatusResolved {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be active or resolved",
+		})
+		return
+	}
+
+	problems, err := repository.GetPatientProblems(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient problems",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	problemList := []services.ProblemListEntry{}
+	for _, entry := range services.BuildProblemList(problems) {
+		if status == "" || entry.Status == status {
+			problemList = append(problemList, entry)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  problemList,
+		"count": len(problemList),
+	})
+}
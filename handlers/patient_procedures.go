@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+/*
+GetPatientProcedures returns a patient's procedure and surgery history, most recent first: each
+procedure's date, surgeon and anesthesia notes with the document that reports it, and the later
+follow-up and pathology documents linked to it. Follow-up references no procedure matched are
+listed under unlinkedFollowUps.
+*/
+func GetPatientProcedures(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	procedures, err := repository.GetPatientProcedures(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient procedures",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	followUps, err := repository.GetPatientProcedureFollowUps(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch procedure follow-ups",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	history, unlinked := services.BuildProcedureHistory(procedures, followUps)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":              history,
+		"count":             len(history),
+		"unlinkedFollowUps": unlinked,
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+)
+
+// timelineYear is one year of a patient's timeline
+type timelineYear struct {
+	Year      int                       `json:"year"`
+	Documents []models.AnalyzedDocument `json:"documents"`
+}
+
+/*
+GetPatientTimeline returns a patient's documents in the order the care happened, grouped by the
+year of their service date, whatever order they had in the uploaded files. Documents without
+a date are listed separately under "undated".
+*/
+func GetPatientTimeline(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	documents, err := repository.GetPatientTimeline(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient timeline",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	years := []timelineYear{}
+	undated := []models.AnalyzedDocument{}
+	for _, document := range documents {
+		if document.ServiceDate == nil {
+			undated = append(undated, document)
+			continue
+		}
+
+		// Documents are already in date order, so a year only ever follows the one before it
+		year := document.ServiceDate.Year()
+		if len(years) == 0 || years[len(years)-1].Year != year {
+			years = append(years, timelineYear{Year: year})
+		}
+		years[len(years)-1].Documents = append(years[len(years)-1].Documents, document)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"years":   years,
+			"undated": undated,
+		},
+		"count": len(documents),
+	})
+}
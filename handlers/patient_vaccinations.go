@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/repository"
+	"PennieAI/services"
+)
+
+const defaultUpcomingVaccineDays = 60
+
+/*
+GetPatientVaccinations returns the vaccines found in a patient's documents and where the
+patient stands against the vaccination schedule for its species: overdue, upcoming (due within
+?upcoming_days=, 60 by default) and current.
+*/
+func GetPatientVaccinations(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	upcomingDays := defaultUpcomingVaccineDays
+	if param := c.Query("upcoming_days"); param != "" {
+		days, err := strconv.Atoi(param)
+		if err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "upcoming_days must be a whole number of days, at least 0",
+			})
+			return
+		}
+		upcomingDays = days
+	}
+
+	vaccinations, err := repository.GetPatientVaccinations(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient vaccinations",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	species, err := repository.GetPatientSpecies(patient.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient species",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"schedule": services.EvaluateVaccinations(species, vaccinations, time.Now(), upcomingDays),
+			"history":  vaccinations,
+		},
+		"count": len(vaccinations),
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+)
+
+// vitalTrend is one vital's readings over time, ready to chart
+type vitalTrend struct {
+	Vital   string                    `json:"vital"`
+	Unit    string                    `json:"unit"`
+	Points  []models.VitalObservation `json:"points"`  // Oldest first
+	Undated []models.VitalObservation `json:"undated"` // From documents without a service date, can't be placed on a chart
+	Latest  *float64                  `json:"latest"`
+	Min     *float64                  `json:"min"`
+	Max     *float64                  `json:"max"`
+}
+
+/*
+GetPatientVitals returns the trend of each vital sign found in a patient's documents: every
+reading in its normalized unit (kg, °C, per minute) with the original text, oldest first.
+?vital= limits the response to one of weight, temperature, heart_rate or respiratory_rate.
+*/
+func GetPatientVitals(c *gin.Context) {
+	patient, ok := findOwnedPatient(c)
+	if !ok {
+		return
+	}
+
+	vital := c.Query("vital")
+	if _, known := models.VitalUnits[vital]; vital != "" && !known {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid vital",
+			"message": fmt.Sprintf("vital must be one of %s", strings.Join([]string{models.VitalWeight, models.VitalTemperature, models.VitalHeartRate, models.VitalRespiratoryRate}, ", ")),
+		})
+		return
+	}
+
+	observations, err := repository.GetPatientVitals(patient.ID, vital)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch patient vitals",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	trends := map[string]*vitalTrend{}
+	for _, observation := range observations {
+		trend, ok := trends[observation.Vital]
+		if !ok {
+			trend = &vitalTrend{
+				Vital:   observation.Vital,
+				Unit:    observation.Unit,
+				Points:  []models.VitalObservation{},
+				Undated: []models.VitalObservation{},
+			}
+			trends[observation.Vital] = trend
+		}
+
+		if observation.ObservedOn == nil {
+			trend.Undated = append(trend.Undated, observation)
+			continue
+		}
+		trend.Points = append(trend.Points, observation)
+
+		value := observation.Value
+		trend.Latest = &value
+		if trend.Min == nil || value < *trend.Min {
+			trend.Min = &value
+		}
+		if trend.Max == nil || value > *trend.Max {
+			trend.Max = &value
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  trends,
+		"count": len(observations),
+	})
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"PennieAI/config"
 	"PennieAI/routes"
+	"PennieAI/services"
 )
 
 func main() {
@@ -46,6 +48,10 @@ func main() {
 		}
 	}()
 
+	// Start background workers for queued document analysis
+	workerCount, _ := strconv.Atoi(os.Getenv("ANALYSIS_WORKERS"))
+	services.StartAnalysisWorkers(workerCount)
+
 	// Set Gin mode based on environment
 	if os.Getenv("GIN_MODE") == "release" {
 		gin.SetMode(gin.ReleaseMode)
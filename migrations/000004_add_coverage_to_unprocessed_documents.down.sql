@@ -0,0 +1,2 @@
+ALTER TABLE unprocessed_documents
+    DROP COLUMN coverage;
@@ -0,0 +1,2 @@
+ALTER TABLE unprocessed_documents
+    ADD COLUMN coverage JSONB;
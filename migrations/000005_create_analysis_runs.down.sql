@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_analyzed_docs_run_id;
+
+ALTER TABLE analyzed_documents
+    DROP COLUMN analysis_run_id;
+
+DROP TRIGGER IF EXISTS update_analysis_runs_updated_at ON analysis_runs;
+DROP INDEX IF EXISTS idx_analysis_runs_current;
+DROP INDEX IF EXISTS idx_analysis_runs_unprocessed_id;
+DROP TABLE IF EXISTS analysis_runs;
+
+DROP INDEX IF EXISTS idx_unprocessed_docs_doctor_id;
+
+ALTER TABLE unprocessed_documents
+    DROP COLUMN doctor_id;
@@ -0,0 +1,49 @@
+-- Track who uploaded each file so re-analysis can be restricted to its owner
+ALTER TABLE unprocessed_documents
+    ADD COLUMN doctor_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_unprocessed_docs_doctor_id ON unprocessed_documents(doctor_id);
+
+-- Each segmentation of an unprocessed document is a run; exactly one run per document is current
+CREATE TABLE analysis_runs (
+                               id SERIAL PRIMARY KEY,
+                               unprocessed_document_id INTEGER NOT NULL REFERENCES unprocessed_documents(id) ON DELETE CASCADE,
+                               patient_id INTEGER REFERENCES patients(id) ON DELETE SET NULL,
+                               model VARCHAR(100),
+                               prompt_version VARCHAR(50),
+                               window_size INTEGER,
+                               overlap_size INTEGER,
+                               parallel BOOLEAN NOT NULL DEFAULT FALSE,
+                               coverage JSONB,
+                               is_current BOOLEAN NOT NULL DEFAULT FALSE,
+                               created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+                               updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_analysis_runs_unprocessed_id ON analysis_runs(unprocessed_document_id);
+CREATE UNIQUE INDEX idx_analysis_runs_current ON analysis_runs(unprocessed_document_id) WHERE is_current;
+
+CREATE TRIGGER update_analysis_runs_updated_at
+    BEFORE UPDATE ON analysis_runs
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE analyzed_documents
+    ADD COLUMN analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_analyzed_docs_run_id ON analyzed_documents(analysis_run_id);
+
+-- Existing uploads become a single current run with unknown settings
+INSERT INTO analysis_runs (unprocessed_document_id, patient_id, coverage, is_current)
+SELECT ud.id,
+       (SELECT ad.patient_id FROM analyzed_documents ad
+        WHERE ad.unprocessed_document_id = ud.id AND ad.patient_id IS NOT NULL
+        LIMIT 1),
+       ud.coverage,
+       TRUE
+FROM unprocessed_documents ud;
+
+UPDATE analyzed_documents ad
+SET analysis_run_id = r.id
+FROM analysis_runs r
+WHERE r.unprocessed_document_id = ad.unprocessed_document_id;
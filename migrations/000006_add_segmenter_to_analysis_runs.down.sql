@@ -0,0 +1,3 @@
+ALTER TABLE analysis_runs
+    DROP COLUMN segmenter,
+    DROP COLUMN boundary_hints;
@@ -0,0 +1,3 @@
+ALTER TABLE analysis_runs
+    ADD COLUMN segmenter VARCHAR(20) NOT NULL DEFAULT 'ai',
+    ADD COLUMN boundary_hints BOOLEAN NOT NULL DEFAULT FALSE;
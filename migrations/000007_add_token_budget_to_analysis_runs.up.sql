@@ -0,0 +1,2 @@
+ALTER TABLE analysis_runs
+    ADD COLUMN token_budget INTEGER;
@@ -0,0 +1,2 @@
+ALTER TABLE analysis_runs
+    DROP COLUMN snap_tolerance;
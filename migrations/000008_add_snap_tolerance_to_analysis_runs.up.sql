@@ -0,0 +1,2 @@
+ALTER TABLE analysis_runs
+    ADD COLUMN snap_tolerance INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- Where each extracted patient field value came from, so a vet can trace it back to the source line
+CREATE TABLE patient_field_sources (
+                                       id SERIAL PRIMARY KEY,
+                                       patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                                       analysis_run_id INTEGER NOT NULL REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                                       analyzed_document_id INTEGER REFERENCES analyzed_documents(id) ON DELETE SET NULL,
+                                       field VARCHAR(50) NOT NULL,
+                                       value TEXT NOT NULL,
+                                       line_number INTEGER,
+                                       line_text TEXT,
+                                       window_index INTEGER,
+                                       inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                                       created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_patient_field_sources_patient ON patient_field_sources(patient_id, field);
+CREATE INDEX idx_patient_field_sources_run ON patient_field_sources(analysis_run_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_analyzed_docs_document_type;
+
+ALTER TABLE analyzed_documents
+    DROP COLUMN document_type_confidence,
+    DROP COLUMN document_type;
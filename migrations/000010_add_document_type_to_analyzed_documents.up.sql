@@ -0,0 +1,6 @@
+-- Documents segmented before classification existed are "other" with no confidence
+ALTER TABLE analyzed_documents
+    ADD COLUMN document_type VARCHAR(50) NOT NULL DEFAULT 'other',
+    ADD COLUMN document_type_confidence REAL NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_analyzed_docs_document_type ON analyzed_documents(document_type);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_analyzed_docs_patient_service_date;
+
+ALTER TABLE analyzed_documents
+    DROP COLUMN service_date;
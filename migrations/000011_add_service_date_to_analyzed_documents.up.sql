@@ -0,0 +1,5 @@
+-- The date the care in a document happened, read from its header. Documents segmented before this are undated.
+ALTER TABLE analyzed_documents
+    ADD COLUMN service_date DATE;
+
+CREATE INDEX idx_analyzed_docs_patient_service_date ON analyzed_documents(patient_id, service_date);
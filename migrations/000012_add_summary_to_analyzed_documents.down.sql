@@ -0,0 +1,4 @@
+ALTER TABLE analyzed_documents
+    DROP COLUMN summary_end_line,
+    DROP COLUMN summary_start_line,
+    DROP COLUMN summary;
@@ -0,0 +1,5 @@
+-- AI summary of each document and the span it was written for, so a summary is regenerated when boundaries move
+ALTER TABLE analyzed_documents
+    ADD COLUMN summary JSONB,
+    ADD COLUMN summary_start_line BIGINT,
+    ADD COLUMN summary_end_line BIGINT;
@@ -0,0 +1,24 @@
+-- Medications read out of each analyzed document, linked to the patient and the lines that mention them
+CREATE TABLE medications (
+                             id SERIAL PRIMARY KEY,
+                             patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                             analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                             analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                             name VARCHAR(255) NOT NULL,
+                             dose NUMERIC,
+                             unit VARCHAR(50),
+                             route VARCHAR(100),
+                             frequency VARCHAR(255),
+                             start_date DATE,
+                             stop_date DATE,
+                             status VARCHAR(20) NOT NULL DEFAULT 'unknown',
+                             prescribed_by VARCHAR(255),
+                             start_line INTEGER,
+                             end_line INTEGER,
+                             source_text TEXT,
+                             inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                             created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_medications_patient ON medications(patient_id);
+CREATE INDEX idx_medications_document ON medications(analyzed_document_id);
@@ -0,0 +1,21 @@
+-- Vaccines administered according to each analyzed document, linked to the patient and the lines that mention them
+CREATE TABLE vaccinations (
+                              id SERIAL PRIMARY KEY,
+                              patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                              analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                              analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                              vaccine VARCHAR(100) NOT NULL,
+                              name VARCHAR(255) NOT NULL,
+                              product VARCHAR(255),
+                              lot_number VARCHAR(100),
+                              administered_on DATE,
+                              next_due_on DATE,
+                              start_line INTEGER,
+                              end_line INTEGER,
+                              source_text TEXT,
+                              inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                              created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_vaccinations_patient ON vaccinations(patient_id, vaccine);
+CREATE INDEX idx_vaccinations_document ON vaccinations(analyzed_document_id);
@@ -0,0 +1,19 @@
+-- Vital signs read from each analyzed document, normalized to one unit per vital with the original text kept
+CREATE TABLE vital_observations (
+                                    id SERIAL PRIMARY KEY,
+                                    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                                    analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                                    analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                                    vital VARCHAR(50) NOT NULL,
+                                    value DOUBLE PRECISION NOT NULL,
+                                    unit VARCHAR(20) NOT NULL,
+                                    original_value DOUBLE PRECISION NOT NULL,
+                                    original_unit VARCHAR(20) NOT NULL,
+                                    original_text TEXT NOT NULL,
+                                    line_number INTEGER NOT NULL,
+                                    observed_on DATE,
+                                    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_vital_observations_patient ON vital_observations(patient_id, vital, observed_on);
+CREATE INDEX idx_vital_observations_document ON vital_observations(analyzed_document_id);
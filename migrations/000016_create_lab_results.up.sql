@@ -0,0 +1,25 @@
+-- Lab values reported in each analyzed document, with the reference range and whether the value was out of it
+CREATE TABLE lab_results (
+                             id SERIAL PRIMARY KEY,
+                             patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                             analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                             analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                             panel VARCHAR(255),
+                             analyte VARCHAR(255) NOT NULL,
+                             value DOUBLE PRECISION,
+                             value_text VARCHAR(255) NOT NULL,
+                             unit VARCHAR(50),
+                             reference_low DOUBLE PRECISION,
+                             reference_high DOUBLE PRECISION,
+                             reference_range VARCHAR(100),
+                             flag VARCHAR(20) NOT NULL DEFAULT 'unknown',
+                             collected_on DATE,
+                             start_line INTEGER,
+                             end_line INTEGER,
+                             source_text TEXT,
+                             inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                             created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_lab_results_patient ON lab_results(patient_id, analyte);
+CREATE INDEX idx_lab_results_document ON lab_results(analyzed_document_id);
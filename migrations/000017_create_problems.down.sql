@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS problems;
+DROP TABLE IF EXISTS problem_terms;
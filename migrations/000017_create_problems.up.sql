@@ -0,0 +1,48 @@
+-- Local controlled vocabulary the extracted diagnoses are coded against. Synonyms are matched
+-- case-insensitively, on their own or as words inside a longer diagnosis.
+CREATE TABLE problem_terms (
+                               id SERIAL PRIMARY KEY,
+                               code VARCHAR(100) NOT NULL UNIQUE,
+                               name VARCHAR(255) NOT NULL,
+                               category VARCHAR(100),
+                               synonyms TEXT[] NOT NULL DEFAULT '{}',
+                               created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+INSERT INTO problem_terms (code, name, category, synonyms) VALUES
+    ('allergic_dermatitis', 'Allergic dermatitis', 'dermatology', '{"atopic dermatitis", "atopy", "environmental allergies", "allergic skin disease", "canine atopic dermatitis"}'),
+    ('pyoderma', 'Pyoderma', 'dermatology', '{"bacterial skin infection", "superficial pyoderma"}'),
+    ('otitis_externa', 'Otitis externa', 'dermatology', '{"ear infection", "otitis"}'),
+    ('skin_mass', 'Skin mass', 'dermatology', '{"cutaneous mass", "skin lump", "subcutaneous mass"}'),
+    ('lipoma', 'Lipoma', 'oncology', '{"benign lipoma", "fatty tumor", "fatty mass"}'),
+    ('dental_tartar', 'Dental tartar', 'dental', '{"tartar", "tartar buildup", "dental calculus", "calculus"}'),
+    ('periodontal_disease', 'Periodontal disease', 'dental', '{"gingivitis", "periodontitis"}'),
+    ('gastroenteritis', 'Gastroenteritis', 'gastrointestinal', '{"acute gastroenteritis", "dietary indiscretion", "gastrointestinal upset", "gi upset"}'),
+    ('dehydration', 'Dehydration', 'gastrointestinal', '{}'),
+    ('hip_dysplasia', 'Hip dysplasia', 'orthopedic', '{"canine hip dysplasia", "dysplasia", "hip subluxation"}'),
+    ('osteoarthritis', 'Osteoarthritis', 'orthopedic', '{"arthritis", "degenerative joint disease", "djd", "osteoarthritic changes"}'),
+    ('heart_murmur', 'Heart murmur', 'cardiology', '{"murmur", "systolic murmur", "cardiac murmur", "incidental systolic murmur"}'),
+    ('obesity', 'Obesity', 'nutrition', '{"overweight", "weight gain"}'),
+    ('seizures', 'Seizures', 'neurology', '{"seizure", "seizure disorder", "epilepsy"}'),
+    ('neurological_disorder', 'Neurological disorder', 'neurology', '{"neurological abnormalities", "neurological process", "ataxia", "proprioceptive deficits", "encephalitis"}'),
+    ('urinary_tract_infection', 'Urinary tract infection', 'urology', '{"uti", "cystitis", "bladder infection"}');
+
+-- Problems and diagnoses read out of each analyzed document, coded against problem_terms when a term matches
+CREATE TABLE problems (
+                          id SERIAL PRIMARY KEY,
+                          patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                          analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                          analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                          problem_term_id INTEGER REFERENCES problem_terms(id) ON DELETE SET NULL,
+                          name VARCHAR(255) NOT NULL,
+                          status VARCHAR(20) NOT NULL DEFAULT 'active',
+                          onset_date DATE,
+                          start_line INTEGER,
+                          end_line INTEGER,
+                          source_text TEXT,
+                          inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                          created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_problems_patient ON problems(patient_id);
+CREATE INDEX idx_problems_document ON problems(analyzed_document_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS procedure_follow_ups;
+DROP TABLE IF EXISTS procedures;
@@ -0,0 +1,38 @@
+-- Procedures and surgeries a document reports as performed
+CREATE TABLE procedures (
+                            id SERIAL PRIMARY KEY,
+                            patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                            analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                            analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                            name VARCHAR(255) NOT NULL,
+                            performed_on DATE,
+                            surgeon VARCHAR(255),
+                            anesthesia TEXT,
+                            start_line INTEGER,
+                            end_line INTEGER,
+                            source_text TEXT,
+                            inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_procedures_patient ON procedures(patient_id);
+CREATE INDEX idx_procedures_document ON procedures(analyzed_document_id);
+
+-- Procedures a document follows up on or reports pathology for, matched to procedures when listing
+CREATE TABLE procedure_follow_ups (
+                                      id SERIAL PRIMARY KEY,
+                                      patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                                      analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                                      analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                                      procedure_name VARCHAR(255) NOT NULL,
+                                      procedure_date DATE,
+                                      role VARCHAR(20) NOT NULL DEFAULT 'follow_up',
+                                      start_line INTEGER,
+                                      end_line INTEGER,
+                                      source_text TEXT,
+                                      inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                                      created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_procedure_follow_ups_patient ON procedure_follow_ups(patient_id);
+CREATE INDEX idx_procedure_follow_ups_document ON procedure_follow_ups(analyzed_document_id);
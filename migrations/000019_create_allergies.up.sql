@@ -0,0 +1,20 @@
+-- Allergies and adverse reactions read out of each analyzed document
+CREATE TABLE allergies (
+                           id SERIAL PRIMARY KEY,
+                           patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+                           analyzed_document_id INTEGER NOT NULL REFERENCES analyzed_documents(id) ON DELETE CASCADE,
+                           analysis_run_id INTEGER REFERENCES analysis_runs(id) ON DELETE CASCADE,
+                           allergen VARCHAR(255) NOT NULL,
+                           kind VARCHAR(20) NOT NULL DEFAULT 'allergy',
+                           category VARCHAR(20) NOT NULL DEFAULT 'other',
+                           reaction TEXT,
+                           severity VARCHAR(20) NOT NULL DEFAULT 'unknown',
+                           start_line INTEGER,
+                           end_line INTEGER,
+                           source_text TEXT,
+                           inference_id INTEGER REFERENCES inferences(id) ON DELETE SET NULL,
+                           created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_allergies_patient ON allergies(patient_id);
+CREATE INDEX idx_allergies_document ON allergies(analyzed_document_id);
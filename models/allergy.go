@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Whether a record is a true allergy or another adverse reaction, e.g. vomiting after a drug
+const (
+	AllergyKindAllergy         = "allergy"
+	AllergyKindAdverseReaction = "adverse_reaction"
+)
+
+// What the patient reacts to
+const (
+	AllergyCategoryDrug          = "drug"
+	AllergyCategoryVaccine       = "vaccine"
+	AllergyCategoryFood          = "food"
+	AllergyCategoryEnvironmental = "environmental"
+	AllergyCategoryOther         = "other"
+)
+
+// How bad the reaction was, from least to most severe
+const (
+	AllergySeverityUnknown  = "unknown"
+	AllergySeverityMild     = "mild"
+	AllergySeverityModerate = "moderate"
+	AllergySeveritySevere   = "severe"
+)
+
+// Allergy is an allergy or adverse reaction a document records for the patient
+type Allergy struct {
+	ID                 int64     `json:"id" db:"id"`
+	PatientID          int64     `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64     `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64    `json:"analysisRunId" db:"analysis_run_id"`
+	Allergen           string    `json:"allergen" db:"allergen"` // e.g. "dust mites", "penicillin"
+	Kind               string    `json:"kind" db:"kind"`
+	Category           string    `json:"category" db:"category"`
+	Reaction           *string   `json:"reaction" db:"reaction"` // e.g. "hives and facial swelling"
+	Severity           string    `json:"severity" db:"severity"`
+	StartLine          *int64    `json:"startLine" db:"start_line"` // 1-based lines of the upload that record the allergy
+	EndLine            *int64    `json:"endLine" db:"end_line"`
+	SourceText         *string   `json:"sourceText" db:"source_text"`
+	InferenceID        *int64    `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time `json:"createdAt" db:"created_at"`
+
+	DocumentTitle *string `json:"documentTitle,omitempty" db:"document_title"` // Only filled when listing
+}
+
+// allergySeverityRanks orders the severities, unknown lowest
+var allergySeverityRanks = map[string]int{
+	AllergySeverityUnknown:  0,
+	AllergySeverityMild:     1,
+	AllergySeverityModerate: 2,
+	AllergySeveritySevere:   3,
+}
+
+// IsAllergySeverity reports whether severity is one of the known severities
+func IsAllergySeverity(severity string) bool {
+	_, ok := allergySeverityRanks[severity]
+	return ok
+}
+
+// MoreSevereThan reports whether a's reaction was worse than b's
+func (a Allergy) MoreSevereThan(b Allergy) bool {
+	return allergySeverityRanks[a.Severity] > allergySeverityRanks[b.Severity]
+}
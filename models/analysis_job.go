@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// AnalysisJob tracks a background document analysis. Jobs live in Redis, not Postgres.
+type AnalysisJob struct {
+	ID               string          `json:"id"`
+	Status           JobStatus       `json:"status"`
+	DoctorID         int             `json:"doctorId"`
+	WindowsCompleted int             `json:"windowsCompleted"`
+	TotalWindows     int             `json:"totalWindows"`
+	ResumedWindows   int             `json:"resumedWindows"` // Windows restored from a checkpoint on retry
+	Attempts         int             `json:"attempts"`       // Number of retries after a failure
+	Result           json.RawMessage `json:"result,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AnalysisRun is one segmentation of an unprocessed document with a given set of settings.
+// Settings are nil for runs created before they were recorded.
+type AnalysisRun struct {
+	ID                    int64           `json:"id" db:"id"`
+	UnprocessedDocumentID int64           `json:"unprocessedDocumentId" db:"unprocessed_document_id"`
+	PatientID             *int64          `json:"patientId" db:"patient_id"`
+	Segmenter             string          `json:"segmenter" db:"segmenter"` // "ai" or "heuristic"
+	BoundaryHints         bool            `json:"boundaryHints" db:"boundary_hints"`
+	Model                 *string         `json:"model" db:"model"`
+	PromptVersion         *string         `json:"promptVersion" db:"prompt_version"`
+	WindowSize            *int            `json:"windowSize" db:"window_size"`
+	OverlapSize           *int            `json:"overlapSize" db:"overlap_size"`
+	TokenBudget           *int            `json:"tokenBudget" db:"token_budget"`     // Nil when windows were sized by line count
+	SnapTolerance         int             `json:"snapTolerance" db:"snap_tolerance"` // 0 when window edges weren't snapped
+	Parallel              bool            `json:"parallel" db:"parallel"`
+	Coverage              *CoverageReport `json:"coverage" db:"coverage"`
+	IsCurrent             bool            `json:"isCurrent" db:"is_current"`
+	CreatedAt             time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt             time.Time       `json:"updatedAt" db:"updated_at"`
+}
@@ -1,17 +1,37 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type AnalyzedDocument struct {
-	ID                    int64     `json:"id" db:"id"`
-	Title                 string    `json:"title" db:"title"`
-	Content               string    `json:"content" db:"content"`
-	NumberOfLines         int64     `json:"numberOfLines" db:"num_lines"`
-	PatientID             int64     `json:"patientId" db:"patient_id"`
-	StartLine             int64     `json:"startLine" db:"start_line"`
-	EndLine               int64     `json:"endLine" db:"end_line"`
-	UnprocessedDocumentId int64     `json:"unprocessedDocumentId" db:"unprocessed_document_id"`
-	CreatedAt             time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt             time.Time `json:"updatedAt" db:"updated_at"`
-	WindowLines           []string  `json:"windowLines" db:"window_lines"`
+	ID                     int64            `json:"id" db:"id"`
+	Title                  string           `json:"title" db:"title"`
+	Content                string           `json:"content" db:"content"`
+	NumberOfLines          int64            `json:"numberOfLines" db:"num_lines"`
+	PatientID              int64            `json:"patientId" db:"patient_id"`
+	StartLine              int64            `json:"startLine" db:"start_line"`
+	EndLine                int64            `json:"endLine" db:"end_line"`
+	UnprocessedDocumentId  int64            `json:"unprocessedDocumentId" db:"unprocessed_document_id"`
+	AnalysisRunID          *int64           `json:"analysisRunId" db:"analysis_run_id"`
+	CreatedAt              time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt              time.Time        `json:"updatedAt" db:"updated_at"`
+	WindowLines            pq.StringArray   `json:"windowLines" db:"window_lines"`
+	DocumentType           string           `json:"documentType" db:"document_type"`                      // One of DocumentTypes
+	DocumentTypeConfidence float64          `json:"documentTypeConfidence" db:"document_type_confidence"` // 0-1, 0 if never classified
+	ServiceDate            *time.Time       `json:"serviceDate" db:"service_date"`                        // When the care happened, nil if the document has no date
+	Summary                *DocumentSummary `json:"summary" db:"summary"`
+	SummaryStartLine       *int64           `json:"summaryStartLine" db:"summary_start_line"` // The lines the summary was written for, it is stale once they differ from the document's
+	SummaryEndLine         *int64           `json:"summaryEndLine" db:"summary_end_line"`
+	// Position of the document's patient in the analysis result, PatientID is set from it when saving
+	PatientIndex int `json:"patientIndex" db:"-"`
+}
+
+// SummaryIsCurrent reports whether the document has a summary written for its current boundaries
+func (d AnalyzedDocument) SummaryIsCurrent() bool {
+	return d.Summary != nil &&
+		d.SummaryStartLine != nil && *d.SummaryStartLine == d.StartLine &&
+		d.SummaryEndLine != nil && *d.SummaryEndLine == d.EndLine
 }
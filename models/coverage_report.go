@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// LineRange is a 1-based, inclusive span of lines in an uploaded file
+type LineRange struct {
+	StartLine int64 `json:"startLine"`
+	EndLine   int64 `json:"endLine"`
+}
+
+// How boundary reconciliation resolved a LineOverlap
+const (
+	OverlapTrimmed = "trimmed" // The losing span kept the lines nobody else claimed
+	OverlapDropped = "dropped" // The losing span was left out entirely
+)
+
+// LineOverlap is a span a window reported that claimed lines of a better centered document,
+// and how reconciliation resolved it
+type LineOverlap struct {
+	LineRange                // The lines both spans claimed
+	Document       string    `json:"document"`       // Title of the document that kept the lines
+	Candidate      string    `json:"candidate"`      // Title of the span that lost them
+	CandidateRange LineRange `json:"candidateRange"` // The losing span as the model reported it
+	WindowIndex    int       `json:"windowIndex"`    // Window that reported the losing span
+	Resolution     string    `json:"resolution"`     // OverlapTrimmed or OverlapDropped
+}
+
+// CoverageReport shows which lines of an upload ended up in an analyzed document
+type CoverageReport struct {
+	TotalLines       int64         `json:"totalLines"`
+	ContentLines     int64         `json:"contentLines"` // Non-blank lines, the ones that matter for coverage
+	AssignedLines    int64         `json:"assignedLines"`
+	CoveragePercent  float64       `json:"coveragePercent"`
+	UnassignedRanges []LineRange   `json:"unassignedRanges"`
+	Overlaps         []LineOverlap `json:"overlaps"` // Overlapping assignments reconciliation resolved
+}
+
+// Value stores the report in a JSONB column
+func (r CoverageReport) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan reads the report back from a JSONB column
+func (r *CoverageReport) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("coverage report: expected []byte from JSONB column")
+	}
+	return json.Unmarshal(bytes, r)
+}
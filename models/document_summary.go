@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// DocumentSummary is the short outcome of a document, so a vet doesn't have to read the whole record
+type DocumentSummary struct {
+	ReasonForVisit string   `json:"reasonForVisit"`
+	Findings       []string `json:"findings"`
+	Plan           []string `json:"plan"`
+	InferenceID    *int64   `json:"inferenceId,omitempty"` // The inference that wrote it, also linked through inferable_type/inferable_id
+}
+
+// Value stores the summary in a JSONB column
+func (s DocumentSummary) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the summary back from a JSONB column
+func (s *DocumentSummary) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("document summary: expected []byte from JSONB column")
+	}
+	return json.Unmarshal(bytes, s)
+}
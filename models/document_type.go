@@ -0,0 +1,38 @@
+package models
+
+// The kinds of records an upload is segmented into. Stored in analyzed_documents.document_type.
+const (
+	DocumentTypeExamination  = "examination_report"
+	DocumentTypeConsultation = "consultation_report"
+	DocumentTypeLab          = "lab_report"
+	DocumentTypeImaging      = "imaging_report"
+	DocumentTypeSurgical     = "surgical_report"
+	DocumentTypeVaccination  = "vaccination_record"
+	DocumentTypeEmail        = "owner_email" // Email to or from the owner, whoever wrote it
+	DocumentTypePhone        = "phone_consultation"
+	DocumentTypeRegistration = "registration_form"
+	DocumentTypeOther        = "other"
+)
+
+// DocumentTypes lists every document type, in the order they are documented
+var DocumentTypes = []string{
+	DocumentTypeExamination,
+	DocumentTypeConsultation,
+	DocumentTypeLab,
+	DocumentTypeImaging,
+	DocumentTypeSurgical,
+	DocumentTypeVaccination,
+	DocumentTypeEmail,
+	DocumentTypePhone,
+	DocumentTypeRegistration,
+	DocumentTypeOther,
+}
+
+func IsDocumentType(documentType string) bool {
+	for _, known := range DocumentTypes {
+		if known == documentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// How a lab value compares to its reference range
+const (
+	LabFlagNormal   = "normal"
+	LabFlagHigh     = "high"
+	LabFlagLow      = "low"
+	LabFlagAbnormal = "abnormal" // Out of range without a direction, e.g. a positive test
+	LabFlagUnknown  = "unknown"
+)
+
+// LabResult is one analyte of a blood count, chemistry panel or other test reported in a document
+type LabResult struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	Panel              *string    `json:"panel" db:"panel"` // e.g. "Complete Blood Count (CBC)"
+	Analyte            string     `json:"analyte" db:"analyte"`
+	Value              *float64   `json:"value" db:"value"` // Nil for results like "Within normal range"
+	ValueText          string     `json:"valueText" db:"value_text"`
+	Unit               *string    `json:"unit" db:"unit"`
+	ReferenceLow       *float64   `json:"referenceLow" db:"reference_low"`
+	ReferenceHigh      *float64   `json:"referenceHigh" db:"reference_high"`
+	ReferenceRange     *string    `json:"referenceRange" db:"reference_range"` // As written in the record
+	Flag               string     `json:"flag" db:"flag"`
+	CollectedOn        *time.Time `json:"collectedOn" db:"collected_on"`
+	StartLine          *int64     `json:"startLine" db:"start_line"` // 1-based lines of the upload the result was read from
+	EndLine            *int64     `json:"endLine" db:"end_line"`
+	SourceText         *string    `json:"sourceText" db:"source_text"`
+	InferenceID        *int64     `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+
+	DocumentTitle *string `json:"documentTitle,omitempty" db:"document_title"` // Only filled when listing
+}
+
+// IsAbnormal reports whether the result is outside its reference range
+func (r LabResult) IsAbnormal() bool {
+	return r.Flag == LabFlagHigh || r.Flag == LabFlagLow || r.Flag == LabFlagAbnormal
+}
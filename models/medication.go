@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// How a medication stood when the document was written
+const (
+	MedicationStatusActive       = "active"
+	MedicationStatusCompleted    = "completed"
+	MedicationStatusDiscontinued = "discontinued"
+	MedicationStatusUnknown      = "unknown"
+)
+
+// A medication of unknown status counts as current for this long after it was started, or
+// after the document that mentions it if the start isn't known
+const unknownStatusCurrentMonths = 6
+
+// Medication is a drug a document says the patient was given or prescribed
+type Medication struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	Name               string     `json:"name" db:"name"`
+	Dose               *float64   `json:"dose" db:"dose"`
+	Unit               *string    `json:"unit" db:"unit"` // e.g. "mg", "mg/kg", "ml"
+	Route              *string    `json:"route" db:"route"`
+	Frequency          *string    `json:"frequency" db:"frequency"`
+	StartDate          *time.Time `json:"startDate" db:"start_date"`
+	StopDate           *time.Time `json:"stopDate" db:"stop_date"`
+	Status             string     `json:"status" db:"status"`
+	PrescribedBy       *string    `json:"prescribedBy" db:"prescribed_by"`
+	StartLine          *int64     `json:"startLine" db:"start_line"` // 1-based lines of the upload that mention the medication
+	EndLine            *int64     `json:"endLine" db:"end_line"`
+	SourceText         *string    `json:"sourceText" db:"source_text"`
+	InferenceID        *int64     `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+
+	DocumentTitle       *string    `json:"documentTitle,omitempty" db:"document_title"`              // Only filled when listing
+	DocumentServiceDate *time.Time `json:"documentServiceDate,omitempty" db:"document_service_date"` // Only filled when listing
+	AllergyConflicts    []string   `json:"allergyConflicts,omitempty" db:"-"`                        // Recorded allergens the medication matches, filled when listing
+}
+
+/*
+IsCurrent reports whether the patient is still on the medication: it wasn't finished or
+stopped, and its stop date, if any, hasn't passed. A record that doesn't say whether the
+medication is ongoing only counts as current for unknownStatusCurrentMonths after its start
+date or its document's service date, and not at all if neither is known.
+*/
+func (m Medication) IsCurrent(today time.Time) bool {
+	if m.Status == MedicationStatusCompleted || m.Status == MedicationStatusDiscontinued {
+		return false
+	}
+	today = today.Truncate(24 * time.Hour)
+	if m.StopDate != nil && m.StopDate.Before(today) {
+		return false
+	}
+	if m.Status == MedicationStatusUnknown && m.StopDate == nil {
+		since := m.StartDate
+		if since == nil {
+			since = m.DocumentServiceDate
+		}
+		return since != nil && !since.AddDate(0, unknownStatusCurrentMonths, 0).Before(today)
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMedicationIsCurrent(t *testing.T) {
+	today := time.Date(2024, time.June, 1, 15, 0, 0, 0, time.UTC)
+	day := func(year int, month time.Month, d int) *time.Time {
+		value := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+		return &value
+	}
+
+	tests := []struct {
+		name       string
+		medication Medication
+		want       bool
+	}{
+		{name: "active", medication: Medication{Status: MedicationStatusActive, StartDate: day(2014, time.January, 1)}, want: true},
+		{name: "active past its stop date", medication: Medication{Status: MedicationStatusActive, StopDate: day(2024, time.May, 31)}},
+		{name: "active until today", medication: Medication{Status: MedicationStatusActive, StopDate: day(2024, time.June, 1)}, want: true},
+		{name: "completed", medication: Medication{Status: MedicationStatusCompleted}},
+		{name: "discontinued", medication: Medication{Status: MedicationStatusDiscontinued, StopDate: day(2025, time.January, 1)}},
+		{name: "unknown and recently started", medication: Medication{Status: MedicationStatusUnknown, StartDate: day(2024, time.March, 1)}, want: true},
+		{name: "unknown from a recent document", medication: Medication{Status: MedicationStatusUnknown, DocumentServiceDate: day(2024, time.January, 15)}, want: true},
+		{name: "unknown from an old document", medication: Medication{Status: MedicationStatusUnknown, DocumentServiceDate: day(2014, time.January, 15)}},
+		{name: "unknown started long ago", medication: Medication{Status: MedicationStatusUnknown, StartDate: day(2023, time.November, 30), DocumentServiceDate: day(2024, time.May, 1)}},
+		{name: "unknown with a future stop date", medication: Medication{Status: MedicationStatusUnknown, StartDate: day(2014, time.January, 1), StopDate: day(2024, time.December, 1)}, want: true},
+		{name: "unknown without dates", medication: Medication{Status: MedicationStatusUnknown}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.medication.IsCurrent(today); got != tt.want {
+				t.Errorf("IsCurrent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
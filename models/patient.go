@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// UnknownPatientName is stored for a patient the analysis found no name for, patients.name is NOT NULL
+const UnknownPatientName = "Unknown"
+
 type Patient struct {
 	ID              int        `json:"id" db:"id"`
 	Name            string     `json:"name" db:"name"`
@@ -11,10 +14,12 @@ type Patient struct {
 	PossibleBreed   *[]string  `json:"possibleBreed" db:"possible_breed"`
 	Sex             *string    `json:"sex" db:"sex"`
 	DateOfBirth     *time.Time `json:"dateOfBirth" db:"date_of_birth"`
-	Weight          *float64   `json:"weight" db:"weight"`
-	Height          *float64   `json:"height" db:"height"`
+	Weight          *float64   `json:"weight" db:"weight"` // kg, the latest weight observation, or the analysis' reading without one
+	Height          *float64   `json:"height" db:"height"` // cm
 	Color           *string    `json:"color" db:"color"`
 	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
 	DoctorId        int        `json:"doctorId" db:"doctor_id"`
+
+	Allergies []Allergy `json:"allergies" db:"-"` // Merged allergies, filled for patient listings and analysis results
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PatientFieldSource records where one value of a patient field came from. A field's sources in
+// window order are its history; for single-valued fields the last one is the value kept.
+type PatientFieldSource struct {
+	ID                 int64     `json:"id" db:"id"`
+	PatientID          int64     `json:"patientId" db:"patient_id"`
+	AnalysisRunID      int64     `json:"analysisRunId" db:"analysis_run_id"`
+	AnalyzedDocumentID *int64    `json:"analyzedDocumentId" db:"analyzed_document_id"` // The document containing LineNumber
+	Field              string    `json:"field" db:"field"`                             // JSON name of the patient field, e.g. "possibleBreed"
+	Value              string    `json:"value" db:"value"`
+	LineNumber         *int64    `json:"lineNumber" db:"line_number"` // 1-based line of the upload, nil if the value isn't in the text verbatim
+	LineText           *string   `json:"lineText" db:"line_text"`
+	WindowIndex        *int      `json:"windowIndex" db:"window_index"` // Nil for heuristic segmentation
+	InferenceID        *int64    `json:"inferenceId" db:"inference_id"` // Nil for heuristic segmentation
+	CreatedAt          time.Time `json:"createdAt" db:"created_at"`
+
+	DocumentTitle *string `json:"documentTitle,omitempty" db:"document_title"` // Only filled when listing
+	// Position of the patient in the analysis result, PatientID is set from it when saving
+	PatientIndex int `json:"-" db:"-"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Whether a problem was still going on when the document was written
+const (
+	ProblemStatusActive   = "active"
+	ProblemStatusResolved = "resolved"
+)
+
+// Problem is a diagnosis or clinical problem one document records for the patient
+type Problem struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	ProblemTermID      *int64     `json:"problemTermId" db:"problem_term_id"` // Nil when no vocabulary term matched
+	Name               string     `json:"name" db:"name"`                     // As written in the record
+	Status             string     `json:"status" db:"status"`
+	OnsetDate          *time.Time `json:"onsetDate" db:"onset_date"`
+	StartLine          *int64     `json:"startLine" db:"start_line"` // 1-based lines of the upload that record the problem
+	EndLine            *int64     `json:"endLine" db:"end_line"`
+	SourceText         *string    `json:"sourceText" db:"source_text"`
+	InferenceID        *int64     `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+
+	// Only filled when listing
+	TermCode            *string    `json:"termCode,omitempty" db:"term_code"`
+	TermName            *string    `json:"termName,omitempty" db:"term_name"`
+	DocumentTitle       *string    `json:"documentTitle,omitempty" db:"document_title"`
+	DocumentServiceDate *time.Time `json:"documentServiceDate,omitempty" db:"document_service_date"`
+}
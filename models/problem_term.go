@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ProblemTerm is an entry of the local vocabulary problems are coded against
+type ProblemTerm struct {
+	ID        int64          `json:"id" db:"id"`
+	Code      string         `json:"code" db:"code"` // e.g. "allergic_dermatitis"
+	Name      string         `json:"name" db:"name"`
+	Category  *string        `json:"category" db:"category"`
+	Synonyms  pq.StringArray `json:"synonyms" db:"synonyms"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+}
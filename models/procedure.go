@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Procedure is a surgery or other procedure a document reports as performed on the patient
+type Procedure struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	Name               string     `json:"name" db:"name"` // e.g. "Surgical excision of skin mass"
+	PerformedOn        *time.Time `json:"performedOn" db:"performed_on"`
+	Surgeon            *string    `json:"surgeon" db:"surgeon"`
+	Anesthesia         *string    `json:"anesthesia" db:"anesthesia"` // Anesthesia notes as the record gives them
+	StartLine          *int64     `json:"startLine" db:"start_line"`  // 1-based lines of the upload that describe the procedure
+	EndLine            *int64     `json:"endLine" db:"end_line"`
+	SourceText         *string    `json:"sourceText" db:"source_text"`
+	InferenceID        *int64     `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+
+	DocumentTitle *string `json:"documentTitle,omitempty" db:"document_title"` // Only filled when listing
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// What a later document adds to a procedure
+const (
+	ProcedureFollowUpRoleFollowUp  = "follow_up"
+	ProcedureFollowUpRolePathology = "pathology" // Reports the histopathology of tissue the procedure removed
+)
+
+// ProcedureFollowUp is a document's reference to an earlier procedure it follows up on
+type ProcedureFollowUp struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	ProcedureName      string     `json:"procedureName" db:"procedure_name"` // As this document names it
+	ProcedureDate      *time.Time `json:"procedureDate" db:"procedure_date"` // Only when the document states it
+	Role               string     `json:"role" db:"role"`
+	StartLine          *int64     `json:"startLine" db:"start_line"`
+	EndLine            *int64     `json:"endLine" db:"end_line"`
+	SourceText         *string    `json:"sourceText" db:"source_text"`
+	InferenceID        *int64     `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+
+	// Only filled when listing
+	DocumentTitle       *string    `json:"documentTitle,omitempty" db:"document_title"`
+	DocumentServiceDate *time.Time `json:"documentServiceDate,omitempty" db:"document_service_date"`
+}
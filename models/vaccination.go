@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Vaccination is a vaccine a document says was administered to the patient
+type Vaccination struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	Vaccine            string     `json:"vaccine" db:"vaccine"` // Normalized key the schedules use, e.g. "rabies", "da2pp"
+	Name               string     `json:"name" db:"name"`       // As written in the record
+	Product            *string    `json:"product" db:"product"`
+	LotNumber          *string    `json:"lotNumber" db:"lot_number"`
+	AdministeredOn     *time.Time `json:"administeredOn" db:"administered_on"`
+	NextDueOn          *time.Time `json:"nextDueOn" db:"next_due_on"` // Only when the record states it
+	StartLine          *int64     `json:"startLine" db:"start_line"`  // 1-based lines of the upload that mention the vaccine
+	EndLine            *int64     `json:"endLine" db:"end_line"`
+	SourceText         *string    `json:"sourceText" db:"source_text"`
+	InferenceID        *int64     `json:"inferenceId" db:"inference_id"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+
+	DocumentTitle *string `json:"documentTitle,omitempty" db:"document_title"` // Only filled when listing
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// The vital signs read from documents, and the unit each is normalized to
+const (
+	VitalWeight          = "weight"           // kg
+	VitalTemperature     = "temperature"      // °C
+	VitalHeartRate       = "heart_rate"       // beats/min
+	VitalRespiratoryRate = "respiratory_rate" // breaths/min
+)
+
+// VitalUnits is the unit every observation of a vital is stored in
+var VitalUnits = map[string]string{
+	VitalWeight:          "kg",
+	VitalTemperature:     "°C",
+	VitalHeartRate:       "beats/min",
+	VitalRespiratoryRate: "breaths/min",
+}
+
+// VitalObservation is one vital sign reading from a document, normalized to VitalUnits with the text it was read from
+type VitalObservation struct {
+	ID                 int64      `json:"id" db:"id"`
+	PatientID          int64      `json:"patientId" db:"patient_id"`
+	AnalyzedDocumentID int64      `json:"analyzedDocumentId" db:"analyzed_document_id"`
+	AnalysisRunID      *int64     `json:"analysisRunId" db:"analysis_run_id"`
+	Vital              string     `json:"vital" db:"vital"`
+	Value              float64    `json:"value" db:"value"`
+	Unit               string     `json:"unit" db:"unit"`
+	OriginalValue      float64    `json:"originalValue" db:"original_value"`
+	OriginalUnit       string     `json:"originalUnit" db:"original_unit"`
+	OriginalText       string     `json:"originalText" db:"original_text"`
+	LineNumber         int64      `json:"lineNumber" db:"line_number"` // 1-based line of the upload
+	ObservedOn         *time.Time `json:"observedOn" db:"observed_on"` // The document's service date
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+}
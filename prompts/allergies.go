@@ -0,0 +1,29 @@
+package prompts
+
+// AllergyExtractionPrompt takes the document's title and its numbered lines
+const AllergyExtractionPrompt = `You are provided with a single veterinary record. List every allergy and every adverse reaction
+the record says the patient has or had: allergens found by allergy testing, foods the patient
+reacts to, and reactions to drugs or vaccines. Don't list allergens that were tested negative,
+statements that no reaction occurred ("no adverse reactions noted"), or instructions to watch
+for a possible reaction.
+
+Use "adverse_reaction" for a reaction that isn't an allergy, such as vomiting after a drug. Use
+the severity the record gives; leave it "unknown" if it gives none. Only use what the record
+says.
+
+Return a structured JSON object in this shape:
+{
+  allergies: {
+    allergen: string;  // e.g. "dust mites", "penicillin", "chicken"
+    kind: "allergy" | "adverse_reaction";
+    category: "drug" | "vaccine" | "food" | "environmental" | "other";
+    reaction: string;  // what happened, e.g. "hives and facial swelling", empty if not stated
+    severity: "mild" | "moderate" | "severe" | "unknown";
+    start_line: number; // first line that records the allergy
+    end_line: number;   // last line that records the allergy
+  }[];
+}
+
+Title: %s
+Here is the record:
+%s`
@@ -1,5 +1,36 @@
 package prompts
 
+import "fmt"
+
+// CurrentDocumentAnalysisVersion is the prompt used when a run doesn't ask for a specific version
+const CurrentDocumentAnalysisVersion = "v2"
+
+// AnalysisPrompt is the base prompt of a version and the notice it adds once earlier windows found something
+type AnalysisPrompt struct {
+	Base              string
+	IncrementalNotice string // Takes the patient JSON and the documents JSON
+	MultiPatient      bool   // Asks for a list of patients and a patient name on every document
+}
+
+// documentAnalysisPrompts maps a prompt version to its prompts. Add new versions here
+// rather than editing old ones, so earlier runs stay reproducible.
+var documentAnalysisPrompts = map[string]AnalysisPrompt{
+	"v1": {Base: BasePrompt, IncrementalNotice: IncrementalNoticeTemplate},
+	"v2": {Base: MultiPatientBasePrompt, IncrementalNotice: MultiPatientIncrementalNoticeTemplate, MultiPatient: true},
+}
+
+// DocumentAnalysisPrompt returns the prompts for a version, or the current ones if version is empty
+func DocumentAnalysisPrompt(version string) (AnalysisPrompt, error) {
+	if version == "" {
+		version = CurrentDocumentAnalysisVersion
+	}
+	prompt, ok := documentAnalysisPrompts[version]
+	if !ok {
+		return AnalysisPrompt{}, fmt.Errorf("unknown prompt version %q", version)
+	}
+	return prompt, nil
+}
+
 const BasePrompt = `You are provided with a chunk of text with line numbers. These lines are part of a sliding window
 across a larger file composed of many distinct documents whose boundaries may be difficult to discern.
 Your task is to determine where each document within this larger file begins and ends. 
@@ -48,3 +79,65 @@ those conflicting / overlapping documents. Do not return documents overlapping w
 identified. Only display new, fully complete documents in the window. Here's a list of the current
 documents' titles and start-end lines:
   %s`
+
+const MultiPatientBasePrompt = `You are provided with a chunk of text with line numbers. These lines are part of a sliding window
+across a larger file composed of many distinct documents whose boundaries may be difficult to discern.
+Your task is to determine where each document within this larger file begins and ends, and which
+patient each document is about.
+Because we're using a sliding window, some documents might begin or end on the boundaries of 
+what you can see. Ignore the documents on the boundaries. Only extract complete documents and 
+patient information from the text.
+
+The start and end lines of different documents will never overlap. There should be relatively few 
+lines between documents, no more than 2-3. So we should expect document lines that look like 1-45,
+48-87, etc and not like 1-45, 78-103, etc where there are significant gaps between the documents.
+
+A file may contain records for more than one patient, for example several pets from the same
+household. List every patient separately and never combine the details of two animals into one
+patient. Each document names the patient it concerns in its "patient" field, using exactly the
+same name as in the patients list.
+
+Return a structured JSON object with the documents and patients in this shape:
+{
+  patients: {
+    name: string;
+    possibleSpecies: string;
+    possibleBreed: string;
+    sex: string;
+    date_of_birth: string; // date in yyyy-MM-dd format
+    weight: string;
+    height: string;
+    color: string;
+  }[];
+  documents: {
+    title: string;
+    start_line: number; // start of document
+    end_line: number;   // end of document
+    patient: string;    // name of the patient the document is about
+  }[];
+}
+`
+
+const MultiPatientIncrementalNoticeTemplate = `Since you are being provided a sliding window, data from previous runs will be made 
+available in order for you to more accurately and completely extract all relevant 
+information from these documents. You will get the patients identified so far with their fields
+filled out, as well as all identified documents: their title, start-end line numbers and patient.
+
+If any patient's data has changed since the last run, or if there is now evidence in the
+documents to fill out fields marked as N/A, return that patient with the new data replacing the
+previous, outdated values. Refer to a patient that was already identified by exactly the same
+name. Only add a new patient if the documents are clearly about a different animal.
+Here are the current patients:
+  %s
+
+If any documents in your current window overlap with existing documents, please disregard 
+those conflicting / overlapping documents. Do not return documents overlapping with those already
+identified. Only display new, fully complete documents in the window. Here's a list of the current
+documents' titles, start-end lines and patients:
+  %s`
+
+const BoundaryHintsTemplate = `A rule-based pre-pass looked for record headers (a title line followed by a line such as
+"Examination Date:" or "Email Date:") and suggests that documents in this chunk may start at
+the lines below. These are hints, not facts: confirm each one against the text, ignore any that
+are wrong, and still find documents the pre-pass missed.
+%s`
@@ -0,0 +1,21 @@
+package prompts
+
+// DocumentSummaryPrompt takes the document's title and its numbered lines
+const DocumentSummaryPrompt = `You are provided with a single veterinary record: an examination report, lab result, owner
+email, phone consultation or similar. Summarize it for a veterinarian who wants the outcome
+without reading the whole record.
+
+Keep every item short, a sentence or less. Only use what the record says; leave a field empty
+rather than guessing. For emails and phone calls the reason for visit is the reason for the
+contact.
+
+Return a structured JSON object in this shape:
+{
+  reason_for_visit: string; // why the patient was seen, or why the owner got in touch
+  findings: string[];       // key exam, test or imaging results and diagnoses
+  plan: string[];           // treatment, medications, follow-up and owner instructions
+}
+
+Title: %s
+Here is the record:
+%s`
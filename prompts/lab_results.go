@@ -0,0 +1,29 @@
+package prompts
+
+// LabResultExtractionPrompt takes the document's title and its numbered lines
+const LabResultExtractionPrompt = `You are provided with a single veterinary record. List every laboratory result it reports:
+blood counts, chemistry panels, urinalysis, thyroid tests, CSF analysis, cytology and similar.
+Give each analyte its own entry, even when several share a line. Leave out tests that were only
+recommended or scheduled, and imaging findings.
+
+Only use what the record says. Leave a field empty rather than guessing; in particular only
+give a reference range the record states.
+
+Return a structured JSON object in this shape:
+{
+  lab_results: {
+    panel: string;           // the panel or test the analyte belongs to, e.g. "Complete Blood Count (CBC)"
+    analyte: string;         // e.g. "White Blood Cells", "Glucose"
+    value: string;           // the result as written, e.g. "12.1", "Within normal range", "Negative"
+    unit: string;            // e.g. "mg/dL", "x10³/µL"
+    reference_range: string; // as written, e.g. "5.5-16.9", empty if not stated
+    flag: "normal" | "high" | "low" | "abnormal" | "unknown"; // what the record says about the value
+    collected_date: string;  // yyyy-MM-dd if the record gives a collection date, otherwise empty
+    start_line: number;      // first line the result is on
+    end_line: number;        // last line the result is on
+  }[];
+}
+
+Title: %s
+Here is the record:
+%s`
@@ -0,0 +1,32 @@
+package prompts
+
+// MedicationExtractionPrompt takes the document's title and its numbered lines
+const MedicationExtractionPrompt = `You are provided with a single veterinary record. List every medication the record says the
+patient was given, prescribed, continued, changed or stopped. Shampoos, ointments and other
+topical treatments count; vaccines don't, leave them out. Don't list medications that were only
+discussed as options.
+
+Only use what the record says. Leave a field empty rather than guessing, for example when the
+record says "as directed on the label" there is no dose or frequency. Use the drug name the
+record uses; if it only names a class ("an NSAID", "oral antihistamine") use that.
+
+Return a structured JSON object in this shape:
+{
+  medications: {
+    name: string;
+    dose: string;          // the amount as a number only, e.g. "0.5"; empty if not stated
+    unit: string;          // unit of the dose, e.g. "mg", "mg/kg", "ml"
+    route: string;         // e.g. "oral", "topical", "subcutaneous"
+    frequency: string;     // e.g. "twice daily", "every other day"
+    start_date: string;    // yyyy-MM-dd, empty if not stated
+    stop_date: string;     // yyyy-MM-dd, empty if not stated
+    status: "active" | "completed" | "discontinued" | "unknown";
+    prescribed_by: string; // the veterinarian who prescribed it
+    start_line: number;    // first line that mentions the medication
+    end_line: number;      // last line that mentions the medication
+  }[];
+}
+
+Title: %s
+Here is the record:
+%s`
@@ -0,0 +1,27 @@
+package prompts
+
+// ProblemExtractionPrompt takes the document's title and its numbered lines
+const ProblemExtractionPrompt = `You are provided with a single veterinary record. List every diagnosis or clinical problem the
+record concludes the patient has or had, as stated in its assessment, diagnosis, impression or
+findings sections. Include incidental findings the veterinarian names (e.g. "minor dental tartar
+buildup", "incidental systolic murmur"). Don't list normal findings, symptoms that were explained
+by a listed diagnosis, or conditions that were only mentioned as possibilities to rule out.
+
+Use a short clinical name for each problem without qualifiers like "mild" or "early", e.g.
+"Hip dysplasia" for "early degenerative changes consistent with a mild form of hip dysplasia".
+A problem is "resolved" only if the record says it cleared up or was cured, otherwise "active".
+
+Return a structured JSON object in this shape:
+{
+  problems: {
+    name: string;
+    status: "active" | "resolved";
+    onset_date: string;  // yyyy-MM-dd if the record says when the problem started, otherwise empty
+    start_line: number;  // first line that records the problem
+    end_line: number;    // last line that records the problem
+  }[];
+}
+
+Title: %s
+Here is the record:
+%s`
@@ -0,0 +1,34 @@
+package prompts
+
+// ProcedureExtractionPrompt takes the document's title and its numbered lines
+const ProcedureExtractionPrompt = `You are provided with a single veterinary record. List the surgeries and other procedures
+under sedation or anesthesia (excisions, dental cleanings, biopsies, spays, neuters, ...) that
+the record reports as performed, and separately the earlier procedures the record follows up on,
+such as a post-operative check or a pathology report on tissue a procedure removed.
+
+Don't list routine examinations, vaccinations, imaging or blood draws as procedures, and don't
+list procedures that were only recommended or scheduled. Only use what the record says; leave a
+field empty rather than guessing.
+
+Return a structured JSON object in this shape:
+{
+  procedures: {
+    name: string;           // e.g. "Surgical excision of skin mass"
+    performed_date: string; // yyyy-MM-dd, empty if not stated
+    surgeon: string;        // the veterinarian who performed it
+    anesthesia: string;     // anesthesia notes as the record gives them, empty if none
+    start_line: number;     // first line that describes the procedure
+    end_line: number;       // last line that describes the procedure
+  }[];
+  follow_ups: {
+    procedure_name: string; // the earlier procedure, as this record names it
+    procedure_date: string; // yyyy-MM-dd if the record states when it was performed, otherwise empty
+    role: "follow_up" | "pathology"; // "pathology" if the record reports pathology of removed tissue
+    start_line: number;
+    end_line: number;
+  }[];
+}
+
+Title: %s
+Here is the record:
+%s`
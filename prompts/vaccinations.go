@@ -0,0 +1,26 @@
+package prompts
+
+// VaccinationExtractionPrompt takes the document's title and its numbered lines
+const VaccinationExtractionPrompt = `You are provided with a single veterinary record. List every vaccine the record says was
+administered to the patient. Leave out vaccines that are only recommended, scheduled or
+mentioned as due; their due date belongs to the vaccine that was given, if the record says
+when the next dose is due.
+
+Only use what the record says. Leave a field empty rather than guessing.
+
+Return a structured JSON object in this shape:
+{
+  vaccinations: {
+    name: string;             // the vaccine as written, e.g. "Rabies", "DA2PP"
+    product: string;          // brand or product name, if given
+    lot_number: string;       // if given
+    administered_date: string; // yyyy-MM-dd, empty if the record doesn't say
+    next_due_date: string;    // yyyy-MM-dd, only if the record states when the next dose is due
+    start_line: number;       // first line that mentions the vaccination
+    end_line: number;         // last line that mentions the vaccination
+  }[];
+}
+
+Title: %s
+Here is the record:
+%s`
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+var ErrAnalysisRunNotFound = errors.New("analysis run not found")
+
+// CreateAnalysisRun inserts a run and fills in the generated id and timestamps
+func CreateAnalysisRun(tx *sqlx.Tx, run *models.AnalysisRun) error {
+	query := `
+		INSERT INTO analysis_runs (unprocessed_document_id, patient_id, segmenter, boundary_hints, model, prompt_version, window_size, overlap_size, token_budget, snap_tolerance, parallel, coverage, is_current)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at`
+
+	return tx.QueryRowx(query,
+		run.UnprocessedDocumentID,
+		run.PatientID,
+		run.Segmenter,
+		run.BoundaryHints,
+		run.Model,
+		run.PromptVersion,
+		run.WindowSize,
+		run.OverlapSize,
+		run.TokenBudget,
+		run.SnapTolerance,
+		run.Parallel,
+		run.Coverage,
+		run.IsCurrent,
+	).Scan(&run.ID, &run.CreatedAt, &run.UpdatedAt)
+}
+
+/*
+SaveAnalysisRun stores a re-analysis of an existing unprocessed document as a new, non-current
+run together with its documents, in a single transaction. Patients with an ID are the ones
+earlier runs created; any new patient the re-analysis found is inserted.
+*/
+func SaveAnalysisRun(run *models.AnalysisRun, patients []*models.Patient, documents []models.AnalyzedDocument, sources []models.PatientFieldSource) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	if err := savePatients(tx, patients); err != nil {
+		return err
+	}
+	// The run records the primary patient, the one most documents belong to
+	if len(patients) > 0 {
+		primaryPatientID := int64(patients[0].ID)
+		run.PatientID = &primaryPatientID
+	}
+
+	run.IsCurrent = false
+	if err := CreateAnalysisRun(tx, run); err != nil {
+		return fmt.Errorf("failed to save analysis run: %w", err)
+	}
+
+	if err := createRunDocuments(tx, run, patients, documents); err != nil {
+		return err
+	}
+
+	if err := createRunFieldSources(tx, run, patients, documents, sources); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit analysis run: %w", err)
+	}
+
+	return nil
+}
+
+// createRunDocuments saves the run's documents, each linked to the patient at its PatientIndex
+func createRunDocuments(tx *sqlx.Tx, run *models.AnalysisRun, patients []*models.Patient, documents []models.AnalyzedDocument) error {
+	for i := range documents {
+		documents[i].UnprocessedDocumentId = run.UnprocessedDocumentID
+		documents[i].AnalysisRunID = &run.ID
+		if index := documents[i].PatientIndex; index >= 0 && index < len(patients) {
+			documents[i].PatientID = int64(patients[index].ID)
+		}
+
+		if err := CreateAnalyzedDocument(tx, &documents[i]); err != nil {
+			return fmt.Errorf("failed to save analyzed document %q: %w", documents[i].Title, err)
+		}
+	}
+	return nil
+}
+
+// PromoteAnalysisRun makes runID the current run of its unprocessed document
+func PromoteAnalysisRun(unprocessedDocumentID int64, runID int64) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Clear the old current run first, the partial unique index allows only one per document
+	_, err = tx.Exec("UPDATE analysis_runs SET is_current = FALSE WHERE unprocessed_document_id = $1 AND is_current", unprocessedDocumentID)
+	if err != nil {
+		return fmt.Errorf("failed to demote current run: %w", err)
+	}
+
+	result, err := tx.Exec("UPDATE analysis_runs SET is_current = TRUE WHERE id = $1 AND unprocessed_document_id = $2", runID, unprocessedDocumentID)
+	if err != nil {
+		return fmt.Errorf("failed to promote run: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrAnalysisRunNotFound
+	}
+
+	// The unprocessed document's coverage always mirrors its current run
+	_, err = tx.Exec(`
+		UPDATE unprocessed_documents
+		SET coverage = (SELECT coverage FROM analysis_runs WHERE id = $1)
+		WHERE id = $2`, runID, unprocessedDocumentID)
+	if err != nil {
+		return fmt.Errorf("failed to update coverage: %w", err)
+	}
+
+	// Patient weights come from the current runs' observations, which just changed
+	if err := refreshPatientWeights(tx, unprocessedDocumentID); err != nil {
+		return fmt.Errorf("failed to update patient weights: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func GetAnalysisRuns(unprocessedDocumentID int64) ([]models.AnalysisRun, error) {
+	db := config.GetDB()
+
+	var runs []models.AnalysisRun
+	err := db.Select(&runs, "SELECT * FROM analysis_runs WHERE unprocessed_document_id = $1 ORDER BY created_at DESC", unprocessedDocumentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func FindAnalysisRun(unprocessedDocumentID int64, runID int64) (models.AnalysisRun, error) {
+	return findAnalysisRun("SELECT * FROM analysis_runs WHERE unprocessed_document_id = $1 AND id = $2", unprocessedDocumentID, runID)
+}
+
+func FindCurrentAnalysisRun(unprocessedDocumentID int64) (models.AnalysisRun, error) {
+	return findAnalysisRun("SELECT * FROM analysis_runs WHERE unprocessed_document_id = $1 AND is_current", unprocessedDocumentID)
+}
+
+func findAnalysisRun(query string, args ...interface{}) (models.AnalysisRun, error) {
+	db := config.GetDB()
+
+	var run models.AnalysisRun
+	err := db.Get(&run, query, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AnalysisRun{}, ErrAnalysisRunNotFound
+		}
+		return models.AnalysisRun{}, err
+	}
+
+	return run, nil
+}
+
+func GetAnalyzedDocumentsByRunID(runID int64) ([]models.AnalyzedDocument, error) {
+	db := config.GetDB()
+
+	var documents []models.AnalyzedDocument
+	err := db.Select(&documents, "SELECT * FROM analyzed_documents WHERE analysis_run_id = $1 ORDER BY start_line", runID)
+	if err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// documentRowColumns are the columns of each per-document table that are copied as they are,
+// the patient, document and run columns are set to the target document's
+var documentRowColumns = map[string]string{
+	"medications": `name, dose, unit, route, frequency, start_date, stop_date, status, prescribed_by,
+	                start_line, end_line, source_text, inference_id`,
+	"vaccinations": `vaccine, name, product, lot_number, administered_on, next_due_on,
+	                 start_line, end_line, source_text, inference_id`,
+	"lab_results": `panel, analyte, value, value_text, unit, reference_low, reference_high, reference_range, flag, collected_on,
+	                start_line, end_line, source_text, inference_id`,
+	"problems": `problem_term_id, name, status, onset_date,
+	             start_line, end_line, source_text, inference_id`,
+	"procedures": `name, performed_on, surgeon, anesthesia,
+	               start_line, end_line, source_text, inference_id`,
+	"procedure_follow_ups": `procedure_name, procedure_date, role,
+	                         start_line, end_line, source_text, inference_id`,
+	"allergies": `allergen, kind, category, reaction, severity,
+	              start_line, end_line, source_text, inference_id`,
+}
+
+// CopyDocumentRows copies one document's rows in each of tables to another document of a later
+// run in one transaction, returning how many rows were copied
+func CopyDocumentRows(fromDocumentID int64, to *models.AnalyzedDocument, tables ...string) (int64, error) {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var copied int64
+	for _, table := range tables {
+		columns, ok := documentRowColumns[table]
+		if !ok {
+			return 0, fmt.Errorf("%s is not a per-document table", table)
+		}
+
+		// table and columns come from documentRowColumns, never from input
+		result, err := tx.Exec(fmt.Sprintf(`
+			INSERT INTO %[1]s (patient_id, analyzed_document_id, analysis_run_id, %[2]s)
+			SELECT $2, $3, $4, %[2]s
+			FROM %[1]s
+			WHERE analyzed_document_id = $1
+			ORDER BY id`, table, columns), fromDocumentID, to.PatientID, to.ID, to.AnalysisRunID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to copy %s: %w", table, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		copied += rows
+	}
+
+	return copied, tx.Commit()
+}
@@ -1,18 +1,41 @@
 package repository
 
-//
-//import (
-//	"PennieAI/config"
-//	"PennieAI/models"
-//	"fmt"
-//)
-//
-//func CreateAnalyzedDocument(doc *models.AnalyzedDocument) error {
-//	db := config.GetDB()
-//	_, err := db.Exec("INSERT INTO analyzed_documents (user_id, title, content, summary, created_at) VALUES ($1, $2, $3, $4, $5)", doc.UserID, doc.Title, doc.Content, doc.Summary, doc.CreatedAt)
-//	if err != nil {
-//		fmt.Println("Error inserting analyzed document into database:", err)
-//		return err
-//	}
-//	return nil
-//}
+import (
+	"github.com/jmoiron/sqlx"
+
+	"PennieAI/models"
+)
+
+// CreateAnalyzedDocument inserts a segmented document and fills in the generated id and timestamps
+func CreateAnalyzedDocument(tx *sqlx.Tx, document *models.AnalyzedDocument) error {
+	// patient_id is nullable, so a zero value is stored as NULL rather than violating the foreign key
+	var patientID *int64
+	if document.PatientID != 0 {
+		patientID = &document.PatientID
+	}
+
+	documentType := document.DocumentType
+	if documentType == "" {
+		documentType = models.DocumentTypeOther
+	}
+
+	query := `
+		INSERT INTO analyzed_documents (title, content, num_lines, patient_id, start_line, end_line, unprocessed_document_id, window_lines, analysis_run_id, document_type, document_type_confidence, service_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at`
+
+	return tx.QueryRowx(query,
+		document.Title,
+		document.Content,
+		document.NumberOfLines,
+		patientID,
+		document.StartLine,
+		document.EndLine,
+		document.UnprocessedDocumentId,
+		document.WindowLines,
+		document.AnalysisRunID,
+		documentType,
+		document.DocumentTypeConfidence,
+		document.ServiceDate,
+	).Scan(&document.ID, &document.CreatedAt, &document.UpdatedAt)
+}
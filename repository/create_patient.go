@@ -2,7 +2,11 @@ package repository
 
 import (
 	"PennieAI/config"
+	"PennieAI/models"
 	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 func CreatePatient(name string, doctorId int) (int, error) {
@@ -25,3 +29,23 @@ func CreatePatient(name string, doctorId int) (int, error) {
 
 	return patientID, nil
 }
+
+// InsertPatient creates a patient from extracted analysis data inside an existing transaction
+func InsertPatient(tx *sqlx.Tx, patient *models.Patient) error {
+	query := `
+		INSERT INTO patients (name, possible_species, possible_breed, sex, date_of_birth, weight, height, color, doctor_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`
+
+	return tx.QueryRowx(query,
+		patient.Name,
+		pq.Array(patient.PossibleSpecies),
+		pq.Array(patient.PossibleBreed),
+		patient.Sex,
+		patient.DateOfBirth,
+		patient.Weight,
+		patient.Height,
+		patient.Color,
+		patient.DoctorId,
+	).Scan(&patient.ID, &patient.CreatedAt, &patient.UpdatedAt)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"PennieAI/models"
+)
+
+func CreatePatientFieldSource(tx *sqlx.Tx, source *models.PatientFieldSource) error {
+	query := `
+		INSERT INTO patient_field_sources (patient_id, analysis_run_id, analyzed_document_id, field, value, line_number, line_text, window_index, inference_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`
+
+	return tx.QueryRowx(query,
+		source.PatientID,
+		source.AnalysisRunID,
+		source.AnalyzedDocumentID,
+		source.Field,
+		source.Value,
+		source.LineNumber,
+		source.LineText,
+		source.WindowIndex,
+		source.InferenceID,
+	).Scan(&source.ID, &source.CreatedAt)
+}
+
+// createRunFieldSources links each source to the run, its patient and the saved document containing its line
+func createRunFieldSources(tx *sqlx.Tx, run *models.AnalysisRun, patients []*models.Patient, documents []models.AnalyzedDocument, sources []models.PatientFieldSource) error {
+	for i := range sources {
+		source := &sources[i]
+		if source.PatientIndex < 0 || source.PatientIndex >= len(patients) {
+			continue
+		}
+		source.PatientID = int64(patients[source.PatientIndex].ID)
+		source.AnalysisRunID = run.ID
+
+		if source.LineNumber != nil {
+			for _, document := range documents {
+				if document.StartLine <= *source.LineNumber && *source.LineNumber <= document.EndLine {
+					documentID := document.ID
+					source.AnalyzedDocumentID = &documentID
+					break
+				}
+			}
+		}
+
+		if err := CreatePatientFieldSource(tx, source); err != nil {
+			return fmt.Errorf("failed to save source of patient field %q: %w", source.Field, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"PennieAI/models"
+)
+
+// CreateUnprocessedDocument inserts the raw uploaded file and fills in the generated id and timestamps
+func CreateUnprocessedDocument(tx *sqlx.Tx, document *models.UnprocessedDocument) error {
+	query := `
+		INSERT INTO unprocessed_documents (content, num_lines, coverage, doctor_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`
+
+	return tx.QueryRowx(query, document.Content, document.NumberOfLines, document.Coverage, document.DoctorID).
+		Scan(&document.ID, &document.CreatedAt, &document.UpdatedAt)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+var ErrAnalyzedDocumentNotFound = errors.New("analyzed document not found")
+
+// FindAnalyzedDocumentForDoctor loads a segmented document only if the given doctor uploaded its file
+func FindAnalyzedDocumentForDoctor(id int64, doctorID int) (models.AnalyzedDocument, error) {
+	db := config.GetDB()
+
+	var document models.AnalyzedDocument
+	err := db.Get(&document, `
+		SELECT ad.*
+		FROM analyzed_documents ad
+		JOIN unprocessed_documents u ON u.id = ad.unprocessed_document_id
+		WHERE ad.id = $1 AND u.doctor_id = $2`, id, doctorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AnalyzedDocument{}, ErrAnalyzedDocumentNotFound
+		}
+		return models.AnalyzedDocument{}, err
+	}
+
+	return document, nil
+}
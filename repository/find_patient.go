@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"PennieAI/models"
+)
+
+var ErrPatientNotFound = errors.New("patient not found")
+
+// FindPatientForDoctor loads a patient only if it belongs to the given doctor. Pass a transaction or the DB.
+func FindPatientForDoctor(q sqlx.Queryer, patientID int, doctorID int) (models.Patient, error) {
+	var patient models.Patient
+
+	err := q.QueryRowx(
+		"SELECT id, name, doctor_id, created_at, updated_at FROM patients WHERE id = $1 AND doctor_id = $2",
+		patientID,
+		doctorID,
+	).StructScan(&patient)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Patient{}, ErrPatientNotFound
+		}
+		return models.Patient{}, err
+	}
+
+	return patient, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+var ErrUnprocessedDocumentNotFound = errors.New("unprocessed document not found")
+
+// FindUnprocessedDocumentForDoctor loads an upload only if the given doctor uploaded it
+func FindUnprocessedDocumentForDoctor(id int64, doctorID int) (models.UnprocessedDocument, error) {
+	db := config.GetDB()
+
+	var document models.UnprocessedDocument
+	err := db.Get(&document, "SELECT * FROM unprocessed_documents WHERE id = $1 AND doctor_id = $2", id, doctorID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UnprocessedDocument{}, ErrUnprocessedDocumentNotFound
+		}
+		return models.UnprocessedDocument{}, err
+	}
+
+	return document, nil
+}
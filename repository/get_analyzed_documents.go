@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"github.com/lib/pq"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetAnalyzedDocuments lists the documents of each upload's current analysis run, newest first.
+// A non-empty documentTypes keeps only documents of those types.
+func GetAnalyzedDocuments(documentTypes []string) ([]models.AnalyzedDocument, error) {
+	db := config.GetDB()
+
+	documents := []models.AnalyzedDocument{}
+	err := db.Select(&documents, `
+		SELECT ad.*
+		FROM analyzed_documents ad
+		LEFT JOIN analysis_runs r ON r.id = ad.analysis_run_id
+		WHERE (ad.analysis_run_id IS NULL OR r.is_current)
+		  AND (cardinality($1::text[]) = 0 OR ad.document_type = ANY($1))
+		ORDER BY ad.created_at DESC, ad.start_line`, pq.Array(documentTypes))
+	if err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/lib/pq"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// allergiesQuery selects the allergies of current analysis runs, most severe first
+const allergiesQuery = `
+	SELECT a.*, ad.title AS document_title
+	FROM allergies a
+	JOIN analyzed_documents ad ON ad.id = a.analyzed_document_id
+	LEFT JOIN analysis_runs r ON r.id = a.analysis_run_id
+	WHERE a.patient_id = ANY($1)
+	  AND (a.analysis_run_id IS NULL OR r.is_current)
+	ORDER BY CASE a.severity WHEN 'severe' THEN 0 WHEN 'moderate' THEN 1 WHEN 'mild' THEN 2 ELSE 3 END,
+	         a.allergen, ad.service_date NULLS LAST, a.id`
+
+// GetPatientAllergies returns a patient's allergies and adverse reactions from current analysis runs, most severe first
+func GetPatientAllergies(patientID int) ([]models.Allergy, error) {
+	return GetAllergiesByPatientIDs([]int{patientID})
+}
+
+// GetAllergiesByPatientIDs returns the allergies of several patients at once, see GetPatientAllergies
+func GetAllergiesByPatientIDs(patientIDs []int) ([]models.Allergy, error) {
+	db := config.GetDB()
+
+	ids := make(pq.Int64Array, len(patientIDs))
+	for i, id := range patientIDs {
+		ids[i] = int64(id)
+	}
+
+	allergies := []models.Allergy{}
+	if err := db.Select(&allergies, allergiesQuery, ids); err != nil {
+		return nil, err
+	}
+
+	return allergies, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+/*
+GetPatientFieldSources returns where a patient's field values came from, oldest first. Only
+current analysis runs count, so a re-analysis that hasn't been promoted doesn't show up.
+An empty field returns the sources of every field.
+*/
+func GetPatientFieldSources(patientID int, field string) ([]models.PatientFieldSource, error) {
+	db := config.GetDB()
+
+	sources := []models.PatientFieldSource{}
+	err := db.Select(&sources, `
+		SELECT s.*, ad.title AS document_title
+		FROM patient_field_sources s
+		JOIN analysis_runs r ON r.id = s.analysis_run_id AND r.is_current
+		LEFT JOIN analyzed_documents ad ON ad.id = s.analyzed_document_id
+		WHERE s.patient_id = $1 AND ($2 = '' OR s.field = $2)
+		ORDER BY s.field, r.created_at, s.window_index NULLS FIRST, s.id`, patientID, field)
+	if err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientLabResults returns a patient's lab results from current analysis runs, newest first.
+// abnormalOnly keeps only high, low and abnormal results; a non-empty analyte matches case-insensitively.
+func GetPatientLabResults(patientID int, abnormalOnly bool, analyte string) ([]models.LabResult, error) {
+	db := config.GetDB()
+
+	results := []models.LabResult{}
+	err := db.Select(&results, `
+		SELECT l.*, ad.title AS document_title
+		FROM lab_results l
+		JOIN analyzed_documents ad ON ad.id = l.analyzed_document_id
+		LEFT JOIN analysis_runs r ON r.id = l.analysis_run_id
+		WHERE l.patient_id = $1
+		  AND (l.analysis_run_id IS NULL OR r.is_current)
+		  AND (NOT $2 OR l.flag IN ('high', 'low', 'abnormal'))
+		  AND ($3 = '' OR l.analyte ILIKE $3)
+		ORDER BY l.collected_on DESC NULLS LAST, l.analyzed_document_id, l.start_line, l.id`, patientID, abnormalOnly, analyte)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
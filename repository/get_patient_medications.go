@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientMedications returns a patient's medications from current analysis runs, most recently started first
+func GetPatientMedications(patientID int) ([]models.Medication, error) {
+	db := config.GetDB()
+
+	medications := []models.Medication{}
+	err := db.Select(&medications, `
+		SELECT m.*, ad.title AS document_title, ad.service_date AS document_service_date
+		FROM medications m
+		JOIN analyzed_documents ad ON ad.id = m.analyzed_document_id
+		LEFT JOIN analysis_runs r ON r.id = m.analysis_run_id
+		WHERE m.patient_id = $1
+		  AND (m.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY COALESCE(m.start_date, ad.service_date) DESC NULLS LAST, m.id`, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return medications, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientProblems returns every problem a patient's documents from current analysis runs
+// record, with its vocabulary term, oldest document first
+func GetPatientProblems(patientID int) ([]models.Problem, error) {
+	db := config.GetDB()
+
+	problems := []models.Problem{}
+	err := db.Select(&problems, `
+		SELECT p.*, t.code AS term_code, t.name AS term_name,
+		       ad.title AS document_title, ad.service_date AS document_service_date
+		FROM problems p
+		JOIN analyzed_documents ad ON ad.id = p.analyzed_document_id
+		LEFT JOIN problem_terms t ON t.id = p.problem_term_id
+		LEFT JOIN analysis_runs r ON r.id = p.analysis_run_id
+		WHERE p.patient_id = $1
+		  AND (p.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY ad.service_date NULLS LAST, ad.start_line, p.id`, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientProcedureFollowUps returns the follow-up references of a patient's documents from current analysis runs, oldest document first
+func GetPatientProcedureFollowUps(patientID int) ([]models.ProcedureFollowUp, error) {
+	db := config.GetDB()
+
+	followUps := []models.ProcedureFollowUp{}
+	err := db.Select(&followUps, `
+		SELECT f.*, ad.title AS document_title, ad.service_date AS document_service_date
+		FROM procedure_follow_ups f
+		JOIN analyzed_documents ad ON ad.id = f.analyzed_document_id
+		LEFT JOIN analysis_runs r ON r.id = f.analysis_run_id
+		WHERE f.patient_id = $1
+		  AND (f.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY ad.service_date NULLS LAST, f.id`, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return followUps, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientProcedures returns a patient's procedures from current analysis runs, most recent first
+func GetPatientProcedures(patientID int) ([]models.Procedure, error) {
+	db := config.GetDB()
+
+	procedures := []models.Procedure{}
+	err := db.Select(&procedures, `
+		SELECT p.*, ad.title AS document_title
+		FROM procedures p
+		JOIN analyzed_documents ad ON ad.id = p.analyzed_document_id
+		LEFT JOIN analysis_runs r ON r.id = p.analysis_run_id
+		WHERE p.patient_id = $1
+		  AND (p.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY p.performed_on DESC NULLS LAST, p.id`, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return procedures, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/lib/pq"
+
+	"PennieAI/config"
+)
+
+// GetPatientSpecies returns the species an analysis thought the patient might be, in the order they were found
+func GetPatientSpecies(patientID int) ([]string, error) {
+	db := config.GetDB()
+
+	var species pq.StringArray
+	if err := db.Get(&species, "SELECT possible_species FROM patients WHERE id = $1", patientID); err != nil {
+		return nil, err
+	}
+
+	return species, nil
+}
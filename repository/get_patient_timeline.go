@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientTimeline returns a patient's documents from current analysis runs, oldest service
+// date first. Undated documents come last, in upload and line order.
+func GetPatientTimeline(patientID int) ([]models.AnalyzedDocument, error) {
+	db := config.GetDB()
+
+	documents := []models.AnalyzedDocument{}
+	err := db.Select(&documents, `
+		SELECT ad.*
+		FROM analyzed_documents ad
+		LEFT JOIN analysis_runs r ON r.id = ad.analysis_run_id
+		WHERE ad.patient_id = $1
+		  AND (ad.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY ad.service_date NULLS LAST, ad.unprocessed_document_id, ad.start_line`, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientVaccinations returns a patient's vaccinations from current analysis runs, oldest first
+func GetPatientVaccinations(patientID int) ([]models.Vaccination, error) {
+	db := config.GetDB()
+
+	vaccinations := []models.Vaccination{}
+	err := db.Select(&vaccinations, `
+		SELECT v.*, ad.title AS document_title
+		FROM vaccinations v
+		JOIN analyzed_documents ad ON ad.id = v.analyzed_document_id
+		LEFT JOIN analysis_runs r ON r.id = v.analysis_run_id
+		WHERE v.patient_id = $1
+		  AND (v.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY v.administered_on NULLS FIRST, v.id`, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return vaccinations, nil
+}
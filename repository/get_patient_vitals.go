@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientVitals returns a patient's vital observations from current analysis runs, oldest
+// first. An empty vital returns every vital.
+func GetPatientVitals(patientID int, vital string) ([]models.VitalObservation, error) {
+	db := config.GetDB()
+
+	observations := []models.VitalObservation{}
+	err := db.Select(&observations, `
+		SELECT o.*
+		FROM vital_observations o
+		LEFT JOIN analysis_runs r ON r.id = o.analysis_run_id
+		WHERE o.patient_id = $1
+		  AND ($2 = '' OR o.vital = $2)
+		  AND (o.analysis_run_id IS NULL OR r.is_current)
+		ORDER BY o.vital, o.observed_on NULLS FIRST, o.line_number`, patientID, vital)
+	if err != nil {
+		return nil, err
+	}
+
+	return observations, nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetPatientsByRunID returns the patients an analysis run's documents are linked to
+func GetPatientsByRunID(runID int64) ([]*models.Patient, error) {
+	db := config.GetDB()
+
+	var patients []*models.Patient
+	err := db.Select(&patients, `
+		SELECT DISTINCT p.id, p.name, p.doctor_id, p.created_at, p.updated_at
+		FROM patients p
+		JOIN analyzed_documents ad ON ad.patient_id = p.id
+		WHERE ad.analysis_run_id = $1
+		ORDER BY p.id`, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	return patients, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// GetProblemTerms returns the problem vocabulary ordered by code
+func GetProblemTerms() ([]models.ProblemTerm, error) {
+	db := config.GetDB()
+
+	terms := []models.ProblemTerm{}
+	if err := db.Select(&terms, "SELECT * FROM problem_terms ORDER BY code"); err != nil {
+		return nil, err
+	}
+
+	return terms, nil
+}
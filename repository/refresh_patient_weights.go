@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/jmoiron/sqlx"
+
+/*
+refreshPatientWeights sets patients.weight to each patient's latest weight observation from a
+current analysis run, for every patient with a document in the upload. Patients with no
+observation keep the weight they have. Pass a transaction or the DB.
+*/
+func refreshPatientWeights(e sqlx.Execer, unprocessedDocumentID int64) error {
+	_, err := e.Exec(`
+		UPDATE patients p
+		SET weight = latest.value, updated_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (o.patient_id) o.patient_id, o.value
+			FROM vital_observations o
+			JOIN analysis_runs r ON r.id = o.analysis_run_id AND r.is_current
+			WHERE o.vital = 'weight'
+			  AND o.patient_id IN (SELECT patient_id FROM analyzed_documents WHERE unprocessed_document_id = $1)
+			ORDER BY o.patient_id, o.observed_on DESC NULLS LAST, o.line_number DESC
+		) latest
+		WHERE p.id = latest.patient_id`, unprocessedDocumentID)
+	return err
+}
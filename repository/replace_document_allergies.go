@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentAllergies stores the allergies extracted from a document in place of any it had
+func ReplaceDocumentAllergies(documentID int64, allergies []models.Allergy) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM allergies WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear allergies: %w", err)
+	}
+
+	for i := range allergies {
+		allergy := &allergies[i]
+		query := `
+			INSERT INTO allergies (patient_id, analyzed_document_id, analysis_run_id, allergen, kind, category, reaction, severity,
+			                       start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			allergy.PatientID,
+			allergy.AnalyzedDocumentID,
+			allergy.AnalysisRunID,
+			allergy.Allergen,
+			allergy.Kind,
+			allergy.Category,
+			allergy.Reaction,
+			allergy.Severity,
+			allergy.StartLine,
+			allergy.EndLine,
+			allergy.SourceText,
+			allergy.InferenceID,
+		).Scan(&allergy.ID, &allergy.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save allergy %q: %w", allergy.Allergen, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentLabResults stores the lab results extracted from a document in place of any it had
+func ReplaceDocumentLabResults(documentID int64, results []models.LabResult) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM lab_results WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear lab results: %w", err)
+	}
+
+	for i := range results {
+		result := &results[i]
+		query := `
+			INSERT INTO lab_results (patient_id, analyzed_document_id, analysis_run_id, panel, analyte, value, value_text, unit,
+			                         reference_low, reference_high, reference_range, flag, collected_on,
+			                         start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			result.PatientID,
+			result.AnalyzedDocumentID,
+			result.AnalysisRunID,
+			result.Panel,
+			result.Analyte,
+			result.Value,
+			result.ValueText,
+			result.Unit,
+			result.ReferenceLow,
+			result.ReferenceHigh,
+			result.ReferenceRange,
+			result.Flag,
+			result.CollectedOn,
+			result.StartLine,
+			result.EndLine,
+			result.SourceText,
+			result.InferenceID,
+		).Scan(&result.ID, &result.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save lab result %q: %w", result.Analyte, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentMedications stores the medications extracted from a document in place of any it had
+func ReplaceDocumentMedications(documentID int64, medications []models.Medication) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM medications WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear medications: %w", err)
+	}
+
+	for i := range medications {
+		medication := &medications[i]
+		query := `
+			INSERT INTO medications (patient_id, analyzed_document_id, analysis_run_id, name, dose, unit, route, frequency,
+			                         start_date, stop_date, status, prescribed_by, start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			medication.PatientID,
+			medication.AnalyzedDocumentID,
+			medication.AnalysisRunID,
+			medication.Name,
+			medication.Dose,
+			medication.Unit,
+			medication.Route,
+			medication.Frequency,
+			medication.StartDate,
+			medication.StopDate,
+			medication.Status,
+			medication.PrescribedBy,
+			medication.StartLine,
+			medication.EndLine,
+			medication.SourceText,
+			medication.InferenceID,
+		).Scan(&medication.ID, &medication.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save medication %q: %w", medication.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
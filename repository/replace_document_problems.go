@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentProblems stores the problems extracted from a document in place of any it had
+func ReplaceDocumentProblems(documentID int64, problems []models.Problem) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM problems WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear problems: %w", err)
+	}
+
+	for i := range problems {
+		problem := &problems[i]
+		query := `
+			INSERT INTO problems (patient_id, analyzed_document_id, analysis_run_id, problem_term_id, name, status,
+			                      onset_date, start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			problem.PatientID,
+			problem.AnalyzedDocumentID,
+			problem.AnalysisRunID,
+			problem.ProblemTermID,
+			problem.Name,
+			problem.Status,
+			problem.OnsetDate,
+			problem.StartLine,
+			problem.EndLine,
+			problem.SourceText,
+			problem.InferenceID,
+		).Scan(&problem.ID, &problem.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save problem %q: %w", problem.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
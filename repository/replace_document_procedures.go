@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentProcedures stores the procedures and follow-up references extracted from a document in place of any it had
+func ReplaceDocumentProcedures(documentID int64, procedures []models.Procedure, followUps []models.ProcedureFollowUp) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM procedures WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear procedures: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM procedure_follow_ups WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear procedure follow-ups: %w", err)
+	}
+
+	for i := range procedures {
+		procedure := &procedures[i]
+		query := `
+			INSERT INTO procedures (patient_id, analyzed_document_id, analysis_run_id, name, performed_on, surgeon, anesthesia,
+			                        start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			procedure.PatientID,
+			procedure.AnalyzedDocumentID,
+			procedure.AnalysisRunID,
+			procedure.Name,
+			procedure.PerformedOn,
+			procedure.Surgeon,
+			procedure.Anesthesia,
+			procedure.StartLine,
+			procedure.EndLine,
+			procedure.SourceText,
+			procedure.InferenceID,
+		).Scan(&procedure.ID, &procedure.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save procedure %q: %w", procedure.Name, err)
+		}
+	}
+
+	for i := range followUps {
+		followUp := &followUps[i]
+		query := `
+			INSERT INTO procedure_follow_ups (patient_id, analyzed_document_id, analysis_run_id, procedure_name, procedure_date, role,
+			                                  start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			followUp.PatientID,
+			followUp.AnalyzedDocumentID,
+			followUp.AnalysisRunID,
+			followUp.ProcedureName,
+			followUp.ProcedureDate,
+			followUp.Role,
+			followUp.StartLine,
+			followUp.EndLine,
+			followUp.SourceText,
+			followUp.InferenceID,
+		).Scan(&followUp.ID, &followUp.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save follow-up of %q: %w", followUp.ProcedureName, err)
+		}
+	}
+
+	return tx.Commit()
+}
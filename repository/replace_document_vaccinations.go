@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentVaccinations stores the vaccinations extracted from a document in place of any it had
+func ReplaceDocumentVaccinations(documentID int64, vaccinations []models.Vaccination) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM vaccinations WHERE analyzed_document_id = $1", documentID); err != nil {
+		return fmt.Errorf("failed to clear vaccinations: %w", err)
+	}
+
+	for i := range vaccinations {
+		vaccination := &vaccinations[i]
+		query := `
+			INSERT INTO vaccinations (patient_id, analyzed_document_id, analysis_run_id, vaccine, name, product, lot_number,
+			                          administered_on, next_due_on, start_line, end_line, source_text, inference_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			vaccination.PatientID,
+			vaccination.AnalyzedDocumentID,
+			vaccination.AnalysisRunID,
+			vaccination.Vaccine,
+			vaccination.Name,
+			vaccination.Product,
+			vaccination.LotNumber,
+			vaccination.AdministeredOn,
+			vaccination.NextDueOn,
+			vaccination.StartLine,
+			vaccination.EndLine,
+			vaccination.SourceText,
+			vaccination.InferenceID,
+		).Scan(&vaccination.ID, &vaccination.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save vaccination %q: %w", vaccination.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
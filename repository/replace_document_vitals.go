@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// ReplaceDocumentVitals stores the vital signs read from a document in place of any it had,
+// then brings the weight of the upload's patients up to date
+func ReplaceDocumentVitals(document *models.AnalyzedDocument, observations []models.VitalObservation) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM vital_observations WHERE analyzed_document_id = $1", document.ID); err != nil {
+		return fmt.Errorf("failed to clear vital observations: %w", err)
+	}
+
+	for i := range observations {
+		observation := &observations[i]
+		query := `
+			INSERT INTO vital_observations (patient_id, analyzed_document_id, analysis_run_id, vital, value, unit,
+			                                original_value, original_unit, original_text, line_number, observed_on)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at`
+
+		err := tx.QueryRowx(query,
+			observation.PatientID,
+			observation.AnalyzedDocumentID,
+			observation.AnalysisRunID,
+			observation.Vital,
+			observation.Value,
+			observation.Unit,
+			observation.OriginalValue,
+			observation.OriginalUnit,
+			observation.OriginalText,
+			observation.LineNumber,
+			observation.ObservedOn,
+		).Scan(&observation.ID, &observation.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save %s observation: %w", observation.Vital, err)
+		}
+	}
+
+	if err := refreshPatientWeights(tx, document.UnprocessedDocumentId); err != nil {
+		return fmt.Errorf("failed to update patient weight: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+/*
+SaveAnalysis writes a complete analysis run in a single transaction:
+  - the uploaded file as an unprocessed document
+  - the extracted patients (created, or linked when a patient's ID is already set)
+  - the analysis run, marked as the document's current run and recorded against patients[0]
+  - every segmented document, pointing at the run and at the patient its PatientIndex names
+  - where each patient field value came from, see models.PatientFieldSource
+
+If any insert fails the transaction is rolled back so no partial run is left behind.
+IDs and timestamps are filled in on the passed structs.
+*/
+func SaveAnalysis(unprocessed *models.UnprocessedDocument, patients []*models.Patient, run *models.AnalysisRun, documents []models.AnalyzedDocument, sources []models.PatientFieldSource) error {
+	db := config.GetDB()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	if err := CreateUnprocessedDocument(tx, unprocessed); err != nil {
+		return fmt.Errorf("failed to save unprocessed document: %w", err)
+	}
+
+	if err := savePatients(tx, patients); err != nil {
+		return err
+	}
+	// The run records the primary patient, the one most documents belong to
+	if len(patients) > 0 {
+		primaryPatientID := int64(patients[0].ID)
+		run.PatientID = &primaryPatientID
+	}
+
+	run.UnprocessedDocumentID = unprocessed.ID
+	run.IsCurrent = true
+	if err := CreateAnalysisRun(tx, run); err != nil {
+		return fmt.Errorf("failed to save analysis run: %w", err)
+	}
+
+	if err := createRunDocuments(tx, run, patients, documents); err != nil {
+		return err
+	}
+
+	if err := createRunFieldSources(tx, run, patients, documents, sources); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit analysis: %w", err)
+	}
+
+	return nil
+}
+
+// savePatients inserts new patients and merges the ones that already have an ID into the stored
+// patient (see UpdateLinkedPatient), so the field sources saved with the run match stored values
+func savePatients(tx *sqlx.Tx, patients []*models.Patient) error {
+	for _, patient := range patients {
+		if patient.ID != 0 {
+			if err := UpdateLinkedPatient(tx, patient); err != nil {
+				return fmt.Errorf("failed to link patient %d: %w", patient.ID, err)
+			}
+		} else if err := InsertPatient(tx, patient); err != nil {
+			return fmt.Errorf("failed to save patient %q: %w", patient.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+// UpdateAnalyzedDocumentSummary stores a document's summary and the span it was written for
+func UpdateAnalyzedDocumentSummary(document *models.AnalyzedDocument) error {
+	db := config.GetDB()
+
+	query := `
+		UPDATE analyzed_documents
+		SET summary = $1, summary_start_line = $2, summary_end_line = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`
+
+	return db.QueryRowx(query,
+		document.Summary,
+		document.SummaryStartLine,
+		document.SummaryEndLine,
+		document.ID,
+	).Scan(&document.UpdatedAt)
+}
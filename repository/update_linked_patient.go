@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"PennieAI/models"
+)
+
+/*
+UpdateLinkedPatient merges an analysis' patient into the existing patient it was linked to,
+checking the patient belongs to the doctor. Fields the analysis found replace the stored ones,
+new species and breeds are added to the stored lists, and the name is only replaced by a real
+one (not models.UnknownPatientName). patient is overwritten with the merged row.
+*/
+func UpdateLinkedPatient(tx *sqlx.Tx, patient *models.Patient) error {
+	name := patient.Name
+	if name == models.UnknownPatientName {
+		name = ""
+	}
+
+	var species, breeds []string
+	err := tx.QueryRowx(`
+		UPDATE patients SET
+			name             = COALESCE(NULLIF($3, ''), name),
+			possible_species = COALESCE(possible_species, '{}') || ARRAY(
+				SELECT value FROM unnest($4::text[]) AS value WHERE value <> ALL(COALESCE(possible_species, '{}'))),
+			possible_breed   = COALESCE(possible_breed, '{}') || ARRAY(
+				SELECT value FROM unnest($5::text[]) AS value WHERE value <> ALL(COALESCE(possible_breed, '{}'))),
+			sex              = COALESCE($6, sex),
+			date_of_birth    = COALESCE($7, date_of_birth),
+			weight           = COALESCE($8, weight),
+			height           = COALESCE($9, height),
+			color            = COALESCE($10, color)
+		WHERE id = $1 AND doctor_id = $2
+		RETURNING id, name, possible_species, possible_breed, sex, date_of_birth, weight, height, color,
+		          doctor_id, created_at, updated_at`,
+		patient.ID,
+		patient.DoctorId,
+		name,
+		pq.Array(patient.PossibleSpecies),
+		pq.Array(patient.PossibleBreed),
+		patient.Sex,
+		patient.DateOfBirth,
+		patient.Weight,
+		patient.Height,
+		patient.Color,
+	).Scan(
+		&patient.ID,
+		&patient.Name,
+		pq.Array(&species),
+		pq.Array(&breeds),
+		&patient.Sex,
+		&patient.DateOfBirth,
+		&patient.Weight,
+		&patient.Height,
+		&patient.Color,
+		&patient.DoctorId,
+		&patient.CreatedAt,
+		&patient.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPatientNotFound
+		}
+		return err
+	}
+
+	patient.PossibleSpecies = &species
+	patient.PossibleBreed = &breeds
+	return nil
+}
@@ -34,16 +34,26 @@ func SetupRoutes(router *gin.Engine) {
 
 		patients := v1.Group("/patients").Use(middleware.AuthRequired())
 		{
-			patients.POST("", handlers.CreatePatient) // POST /api/v1/patients
-			patients.GET("", handlers.GetPatients)    // GET /api/v1/patients
+			patients.POST("", handlers.CreatePatient)                          // POST /api/v1/patients
+			patients.GET("", handlers.GetPatients)                             // GET /api/v1/patients
+			patients.GET("/:id/provenance", handlers.GetPatientFieldSources)   // GET /api/v1/patients/:id/provenance?field=
+			patients.GET("/:id/allergies", handlers.GetPatientAllergies)       // GET /api/v1/patients/:id/allergies
+			patients.GET("/:id/timeline", handlers.GetPatientTimeline)         // GET /api/v1/patients/:id/timeline
+			patients.GET("/:id/medications", handlers.GetPatientMedications)   // GET /api/v1/patients/:id/medications
+			patients.GET("/:id/vaccinations", handlers.GetPatientVaccinations) // GET /api/v1/patients/:id/vaccinations?upcoming_days=
+			patients.GET("/:id/labs", handlers.GetPatientLabResults)           // GET /api/v1/patients/:id/labs?abnormal=&analyte=
+			patients.GET("/:id/problems", handlers.GetPatientProblems)         // GET /api/v1/patients/:id/problems?status=
+			patients.GET("/:id/procedures", handlers.GetPatientProcedures)     // GET /api/v1/patients/:id/procedures
+			patients.GET("/:id/vitals", handlers.GetPatientVitals)             // GET /api/v1/patients/:id/vitals?vital=
 		}
 
 		documents := v1.Group("/documents").Use(middleware.AuthRequired())
 		{
-			documents.GET("", handlers.GetAllAnalyzedDocuments) // GET /api/v1/documents
-			documents.GET("/:id", handlers.GetDocumentByID)     // GET /api/v1/documents/:id
-			documents.POST("", handlers.CreateDocument)         // POST /api/v1/documents
-			documents.DELETE("/:id", handlers.DeleteDocument)   // DELETE /api/v1/documents/:id
+			documents.GET("", handlers.GetAllAnalyzedDocuments)        // GET /api/v1/documents
+			documents.GET("/:id", handlers.GetDocumentByID)            // GET /api/v1/documents/:id
+			documents.POST("", handlers.CreateDocument)                // POST /api/v1/documents
+			documents.DELETE("/:id", handlers.DeleteDocument)          // DELETE /api/v1/documents/:id
+			documents.POST("/:id/summary", handlers.SummarizeDocument) // POST /api/v1/documents/:id/summary?force=
 		}
 
 		aiTool := v1.Group("/ai_tool").Use(middleware.AuthRequired())
@@ -58,6 +68,26 @@ func SetupRoutes(router *gin.Engine) {
 			unprocessedDocuments.POST("/analyze",
 				middleware.OpenAIRateLimiter(),
 				handlers.AnalyzeUnprocessedDocument)
+			unprocessedDocuments.POST("/analyze/async",
+				middleware.OpenAIRateLimiter(),
+				handlers.EnqueueDocumentAnalysis) // POST /api/v1/unprocessed/analyze/async
+			unprocessedDocuments.POST("/analyze/stream",
+				middleware.OpenAIRateLimiter(),
+				handlers.AnalyzeUnprocessedDocumentStream) // POST /api/v1/unprocessed/analyze/stream (SSE)
+			unprocessedDocuments.POST("/:id/reanalyze",
+				middleware.OpenAIRateLimiter(),
+				handlers.ReanalyzeUnprocessedDocument) // POST /api/v1/unprocessed/:id/reanalyze
+			unprocessedDocuments.GET("/:id/runs", handlers.GetAnalysisRuns)                    // GET /api/v1/unprocessed/:id/runs
+			unprocessedDocuments.GET("/:id/runs/:runId/compare", handlers.CompareAnalysisRuns) // GET /api/v1/unprocessed/:id/runs/:runId/compare?base=
+			unprocessedDocuments.POST("/:id/runs/:runId/promote", handlers.PromoteAnalysisRun) // POST /api/v1/unprocessed/:id/runs/:runId/promote
+		}
+
+		jobs := v1.Group("/jobs").Use(middleware.AuthRequired())
+		{
+			jobs.GET("/:id", handlers.GetJob) // GET /api/v1/jobs/:id
+			jobs.POST("/:id/retry",
+				middleware.OpenAIRateLimiter(),
+				handlers.RetryJob) // POST /api/v1/jobs/:id/retry
 		}
 	}
 
@@ -7,141 +7,577 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"mime/multipart"
+	"log"
+	"runtime/debug"
 	"strings"
+
+	"golang.org/x/sync/errgroup"
 )
 
-func AnalyzeDocument(file *multipart.FileHeader, aiService *AIService) (*models.Patient, []models.AnalyzedDocument, error) {
+const (
+	defaultWindowConcurrency = 4
+	// MaxWindowConcurrency caps the in-flight window queries of one analysis, so a single request
+	// can't use up the OpenAI rate limit on its own
+	MaxWindowConcurrency = 8
+	// MaxAnalysisWindows caps the AI calls one analysis makes, whatever its window settings
+	MaxAnalysisWindows = 100
+)
 
-	var patient models.Patient
-	var analyzedDocuments []models.AnalyzedDocument
+// Segmenters that can produce a DocumentAnalysis
+const (
+	SegmenterAI        = "ai"
+	SegmenterHeuristic = "heuristic" // Rule-based, no AI calls, see DetectDocumentBoundaries
+)
+
+// AnalysisSettings control how a file is segmented. They are serializable so queued jobs can carry them.
+// Zero values (nil for OverlapSize, where 0 is a valid choice) fall back to the defaults, see Resolved.
+type AnalysisSettings struct {
+	Parallel      bool   `json:"parallel"`              // Query all windows concurrently, then merge
+	Concurrency   int    `json:"concurrency,omitempty"` // Max in-flight window queries in parallel mode
+	Model         string `json:"model,omitempty"`
+	PromptVersion string `json:"promptVersion,omitempty"`
+	WindowSize    int    `json:"windowSize,omitempty"`
+	OverlapSize   *int   `json:"overlapSize,omitempty"`
+	TokenBudget   int    `json:"tokenBudget,omitempty"`   // Max estimated prompt tokens per window, 0 sizes windows by WindowSize lines
+	SnapTolerance int    `json:"snapTolerance,omitempty"` // Lines a window edge may move to land on a header or blank line
+	Segmenter     string `json:"segmenter,omitempty"`
+	// Include the rule-based boundary candidates in each window's prompt
+	BoundaryHints bool `json:"boundaryHints"`
+	// Return the rule-based segmentation instead of an error when the AI service fails
+	HeuristicFallback bool `json:"heuristicFallback"`
+	// Run the AI document extractors (summary, medications, ...) on the saved documents, see ProcessDocuments
+	Extract bool `json:"extract"`
+}
+
+// Resolved fills in defaults and validates the settings, so what a run records is what it used
+func (s AnalysisSettings) Resolved() (AnalysisSettings, error) {
+	if s.Segmenter == "" {
+		s.Segmenter = SegmenterAI
+	}
+	if s.Segmenter != SegmenterAI && s.Segmenter != SegmenterHeuristic {
+		return s, fmt.Errorf("unknown segmenter %q", s.Segmenter)
+	}
+	if s.Model == "" {
+		s.Model = GetModelVersion()
+	}
+	if s.PromptVersion == "" {
+		s.PromptVersion = prompts.CurrentDocumentAnalysisVersion
+	}
+	if _, err := prompts.DocumentAnalysisPrompt(s.PromptVersion); err != nil {
+		return s, err
+	}
+	if s.WindowSize == 0 {
+		s.WindowSize = utils.DefaultWindowSize
+	}
+	if s.OverlapSize == nil {
+		// Keep the default window-to-overlap ratio
+		overlapSize := s.WindowSize * utils.DefaultOverlapSize / utils.DefaultWindowSize
+		s.OverlapSize = &overlapSize
+	}
+	if err := s.windowOptions().Validate(); err != nil {
+		return s, err
+	}
+	if limit := ModelPromptLimit(s.Model); s.TokenBudget > limit {
+		return s, fmt.Errorf("token budget %d is more than the %d prompt tokens %s allows", s.TokenBudget, limit, s.Model)
+	}
+	return s, nil
+}
+
+// windowOptions are the settings' window options, only complete once the settings are resolved
+func (s AnalysisSettings) windowOptions() utils.WindowOptions {
+	return utils.WindowOptions{WindowSize: s.WindowSize, OverlapSize: s.overlapSize(), TokenBudget: s.TokenBudget, SnapTolerance: s.SnapTolerance}
+}
 
-	fileLines, err := utils.GetFileLines(file)
+func (s AnalysisSettings) overlapSize() int {
+	if s.OverlapSize == nil {
+		return 0
+	}
+	return *s.OverlapSize
+}
+
+// AnalyzeOptions lets callers configure and observe a running analysis
+type AnalyzeOptions struct {
+	Settings         AnalysisSettings
+	Checkpoint       bool                 // Save each window's response and resume from earlier saves, see checkpointKey
+	OnWindowComplete func(WindowProgress) // Called after each window's results are merged
+	// Called after each saved document's extractors are done, see ProcessDocuments
+	OnDocumentExtracted func(DocumentExtraction)
+}
+
+// WindowProgress describes how far an analysis has gotten and what the latest window found
+type WindowProgress struct {
+	WindowIndex  int                       `json:"windowIndex"`
+	TotalWindows int                       `json:"totalWindows"`
+	StartLine    int                       `json:"startLine"` // 1-based, inclusive
+	EndLine      int                       `json:"endLine"`   // 1-based, inclusive
+	NewDocuments []models.AnalyzedDocument `json:"newDocuments"`
+	Patients     []models.Patient          `json:"patients"` // Merged patients as of this window
+	// Documents from this window that were skipped because the model's output was unusable
+	ValidationErrors []DocumentValidationError `json:"validationErrors"`
+}
+
+// documentSummary is the slice of a document the incremental notice shows the model
+type documentSummary struct {
+	Title     string `json:"title"`
+	StartLine int64  `json:"start_line"`
+	EndLine   int64  `json:"end_line"`
+	Patient   string `json:"patient,omitempty"` // Only shown to multi-patient prompts
+}
+
+// DocumentAnalysis is everything AnalyzeDocument found in a file
+type DocumentAnalysis struct {
+	Patients     []*models.Patient // Primary patient first, see finalizePatients
+	FieldSources []models.PatientFieldSource
+	Documents    []models.AnalyzedDocument
+	Gaps         []BoundaryGap        // Unexpectedly large spaces between documents
+	Overlaps     []models.LineOverlap // Spans that claimed another document's lines, see reconcileBoundaries
+	// Documents the model returned that couldn't be used, see mergeWindowResponse
+	ValidationErrors []DocumentValidationError
+	// Windows whose responses came from a checkpoint instead of a new AI query
+	ResumedWindows int
+	Segmenter      string // Which segmenter produced the result, differs from the settings after a fallback
+}
+
+/*
+AnalyzeDocument splits the file into sliding windows and asks the AI service to find the
+patients and the documents in each one. Every window's document spans are kept as candidates
+and reconciled into one non-overlapping list (see reconcileBoundaries).
+
+By default windows run sequentially so each prompt can include what earlier windows found.
+With Settings.Parallel every window is queried independently and concurrently, then the
+responses are merged in window order, so the output doesn't depend on which call finished first.
+Cancelling ctx stops the window queries that haven't finished and fails the analysis.
+*/
+func AnalyzeDocument(ctx context.Context, fileLines []string, aiService *AIService, opts *AnalyzeOptions) (*DocumentAnalysis, error) {
+	if opts == nil {
+		opts = &AnalyzeOptions{}
+	}
+
+	settings, err := opts.Settings.Resolved()
 	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("invalid analysis settings: %w", err)
 	}
+	opts.Settings = settings
 
-	windows := utils.WindowBuilder(fileLines, nil)
+	if settings.Segmenter == SegmenterHeuristic {
+		return SegmentDocumentHeuristically(fileLines), nil
+	}
+
+	var boundaryHints []BoundaryCandidate
+	if settings.BoundaryHints {
+		boundaryHints = DetectDocumentBoundaries(fileLines)
+	}
 
-	for _, window := range windows {
-		// Build incremental notice if we have previous documents
-		// This tells OpenAI what we've already found in earlier windows to avoid duplicates
-		var incrementalNotice string
+	analysisPrompt, _ := prompts.DocumentAnalysisPrompt(settings.PromptVersion)
 
-		var promptBuilder strings.Builder
-		promptBuilder.WriteString(prompts.BasePrompt)
+	windowOptions := settings.windowOptions()
+	if settings.SnapTolerance > 0 {
+		// Snap window edges to the same headers the heuristic segmenter would split on
+		for _, boundary := range DetectDocumentBoundaries(fileLines) {
+			windowOptions.HeaderLines = append(windowOptions.HeaderLines, int(boundary.StartLine-1))
+		}
+	}
+	if settings.TokenBudget > 0 {
+		// Parallel windows never get the incremental notice, so this is their whole overhead.
+		// Sequential windows are re-sized below as the notice grows.
+		windowOptions.ReservedTokens = promptOverheadTokens(analysisPrompt, 0, fileLines, nil, nil, boundaryHints)
+		if err := windowOptions.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	windows := utils.WindowBuilder(fileLines, &windowOptions)
+	if len(windows) > MaxAnalysisWindows {
+		return nil, fmt.Errorf("the settings split the file into %d windows, more than the %d an analysis may query; use larger windows or a larger token budget", len(windows), MaxAnalysisWindows)
+	}
 
-		if len(analyzedDocuments) > 0 {
-			patientJSON, _ := json.MarshalIndent(patient, "  ", "  ")
-			docsJSON, _ := json.MarshalIndent(analyzedDocuments, "  ", "  ")
-			incrementalNotice = fmt.Sprintf(prompts.IncrementalNoticeTemplate, patientJSON, docsJSON)
+	var checkpointed map[int]windowResponse
+	var key string
+	if opts.Checkpoint {
+		key = checkpointKey(fileLines, settings)
+		checkpointed = loadCheckpoint(ctx, key)
+	}
 
-			promptBuilder.WriteString("\n")
+	if settings.Parallel {
+		analysis, err := analyzeWindowsInParallel(ctx, windows, fileLines, analysisPrompt, boundaryHints, aiService, opts, key, checkpointed)
+		if err != nil {
+			return fallBackToHeuristic(ctx, fileLines, settings, err)
+		}
+		return analysis, nil
+	}
 
-			promptBuilder.WriteString(incrementalNotice)
+	var patients patientSet
+	var candidates []documentCandidate
+	var analyzedDocuments []models.AnalyzedDocument
+	var gaps []BoundaryGap
+	var overlaps []models.LineOverlap
+	var validationErrors []DocumentValidationError
+	resumedWindows := 0
 
-			promptBuilder.WriteString("\n")
+	for windowIndex := 0; windowIndex < len(windows); windowIndex++ {
+		if settings.TokenBudget > 0 && windowIndex > 0 {
+			// The notice of earlier documents takes more of the budget every window, so the rest of
+			// the windows are rebuilt from here with what is left
+			startIndex := windows[windowIndex].StartIndex
+			windowOptions.ReservedTokens = promptOverheadTokens(analysisPrompt, startIndex, fileLines, patients.patients, analyzedDocuments, boundaryHints)
+			if err := windowOptions.Validate(); err != nil {
+				return nil, fmt.Errorf("window %d: %w", windowIndex+1, err)
+			}
+			windows = append(windows[:windowIndex], utils.WindowBuilderFrom(fileLines, startIndex, &windowOptions)...)
+			if len(windows) > MaxAnalysisWindows {
+				return nil, fmt.Errorf("the token budget leaves room for so few lines that the file needs more than %d windows", MaxAnalysisWindows)
+			}
 		}
-		promptBuilder.WriteString("Here is the text chunk:\n")
+		window := windows[windowIndex]
+
+		// Sequential checkpoints are always a prefix of the windows, so replaying them keeps every later prompt identical
+		response, resumed := checkpointed[windowIndex]
+		if resumed {
+			resumedWindows++
+		} else {
+			prompt := buildWindowPrompt(analysisPrompt, window, patients.patients, analyzedDocuments, boundaryHints)
+
+			response, err = queryWindow(ctx, aiService, prompt, analysisPrompt, settings.Model)
+
+			if err != nil {
+				return fallBackToHeuristic(ctx, fileLines, settings, fmt.Errorf("AI query failed on window %d of %d: %w", windowIndex+1, len(windows), err))
+			}
 
-		for lineIndex, line := range window.WindowLines {
-			lineNumber := window.StartIndex + lineIndex + 1
-			promptBuilder.WriteString(fmt.Sprintf("%d: %s\n", lineNumber, line))
+			if opts.Checkpoint {
+				saveCheckpoint(ctx, key, windowIndex, response)
+			}
 		}
 
-		analyzedDocuments = append(analyzedDocuments, models.AnalyzedDocument{
-			Title:     fmt.Sprintf("test_%d", window.StartIndex),
-			StartLine: 0,
-			EndLine:   1,
-		})
+		var windowErrors []DocumentValidationError
+		candidates, windowErrors = mergeWindowResponse(&patients, candidates, window, windowIndex, response)
+		validationErrors = append(validationErrors, windowErrors...)
 
-		response, err := aiService.Query(context.Background(), promptBuilder.String(), nil)
+		previousDocuments := analyzedDocuments
+		analyzedDocuments, gaps, overlaps = reconcileBoundaries(candidates, fileLines)
 
-		if err != nil {
-			return nil, nil, fmt.Errorf("AI query failed: %w", err)
+		notifyWindowComplete(opts, windowIndex, windows, newDocuments(previousDocuments, analyzedDocuments), patients.patients, windowErrors)
+	}
+
+	return &DocumentAnalysis{
+		Patients:         finalizePatients(patients.patients, analyzedDocuments, patients.sources),
+		FieldSources:     patients.sources,
+		Documents:        analyzedDocuments,
+		Gaps:             gaps,
+		Overlaps:         overlaps,
+		ValidationErrors: validationErrors,
+		ResumedWindows:   resumedWindows,
+		Segmenter:        SegmenterAI,
+	}, nil
+}
+
+// fallBackToHeuristic returns the rule-based segmentation if the settings allow it, otherwise the
+// AI error. A cancelled analysis isn't an AI failure, so it never falls back.
+func fallBackToHeuristic(ctx context.Context, fileLines []string, settings AnalysisSettings, aiErr error) (*DocumentAnalysis, error) {
+	if !settings.HeuristicFallback || ctx.Err() != nil {
+		return nil, aiErr
+	}
+
+	log.Printf("⚠️  %v, falling back to heuristic segmentation", aiErr)
+	return SegmentDocumentHeuristically(fileLines), nil
+}
+
+func analyzeWindowsInParallel(ctx context.Context, windows []utils.Window, fileLines []string, analysisPrompt prompts.AnalysisPrompt, boundaryHints []BoundaryCandidate, aiService *AIService, opts *AnalyzeOptions, key string, checkpointed map[int]windowResponse) (*DocumentAnalysis, error) {
+	concurrency := windowConcurrency(opts.Settings.Concurrency)
+
+	// Each goroutine writes only its own index, so no locking is needed
+	responses := make([]windowResponse, len(windows))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+
+	resumedWindows := 0
+	for windowIndex, window := range windows {
+		if response, ok := checkpointed[windowIndex]; ok {
+			responses[windowIndex] = response
+			resumedWindows++
+			continue
 		}
 
-		fmt.Printf("OpenAI Response: %+v\n", response)
+		group.Go(func() (err error) {
+			// A panic here would take down the whole process, not just this analysis
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					log.Printf("⚠️  Window %d panicked: %v\n%s", windowIndex, recovered, debug.Stack())
+					err = fmt.Errorf("window %d panicked: %v", windowIndex, recovered)
+				}
+			}()
+
+			// No incremental notice: windows can't see each other's results in this mode
+			prompt := buildWindowPrompt(analysisPrompt, window, nil, nil, boundaryHints)
 
-		// See Q&A 2025-10-14 for more info on this syntax
-		if patientData, ok := response["patient"].(map[string]interface{}); ok {
-			// Merge patient fields (prefer non-empty values)
-			if name, ok := patientData["name"].(string); ok && name != "" {
-				patient.Name = name
+			response, err := queryWindow(groupCtx, aiService, prompt, analysisPrompt, opts.Settings.Model)
+			if err != nil {
+				return fmt.Errorf("AI query failed for window %d: %w", windowIndex, err)
 			}
-			if species, ok := patientData["possibleSpecies"].(string); ok && species != "" {
-				// First, check if the pointer is nil
-				if patient.PossibleSpecies == nil {
-					// If it's nil, initialize it with a pointer to an empty slice
-					patient.PossibleSpecies = &[]string{}
-				}
 
-				newPossibleSpecies := true
+			responses[windowIndex] = response
+			if opts.Checkpoint {
+				// Not the group context: a sibling failing shouldn't stop this window being saved
+				saveCheckpoint(ctx, key, windowIndex, response)
+			}
+			return nil
+		})
+	}
 
-				for _, possibleSpecies := range *patient.PossibleSpecies {
-					if possibleSpecies == species {
-						newPossibleSpecies = false
-					}
-				}
-				if newPossibleSpecies {
-					*patient.PossibleSpecies = append(*patient.PossibleSpecies, species)
-				}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Merge pass runs in window order, the same order the sequential mode uses
+	var patients patientSet
+	var candidates []documentCandidate
+	var analyzedDocuments []models.AnalyzedDocument
+	var gaps []BoundaryGap
+	var overlaps []models.LineOverlap
+	var validationErrors []DocumentValidationError
+
+	for windowIndex, window := range windows {
+		var windowErrors []DocumentValidationError
+		candidates, windowErrors = mergeWindowResponse(&patients, candidates, window, windowIndex, responses[windowIndex])
+		validationErrors = append(validationErrors, windowErrors...)
+
+		previousDocuments := analyzedDocuments
+		analyzedDocuments, gaps, overlaps = reconcileBoundaries(candidates, fileLines)
+
+		notifyWindowComplete(opts, windowIndex, windows, newDocuments(previousDocuments, analyzedDocuments), patients.patients, windowErrors)
+	}
+
+	return &DocumentAnalysis{
+		Patients:         finalizePatients(patients.patients, analyzedDocuments, patients.sources),
+		FieldSources:     patients.sources,
+		Documents:        analyzedDocuments,
+		Gaps:             gaps,
+		Overlaps:         overlaps,
+		ValidationErrors: validationErrors,
+		ResumedWindows:   resumedWindows,
+		Segmenter:        SegmenterAI,
+	}, nil
+}
+
+// windowConcurrency is how many window queries run at once for the requested concurrency
+func windowConcurrency(requested int) int {
+	if requested <= 0 {
+		return defaultWindowConcurrency
+	}
+	return min(requested, MaxWindowConcurrency)
+}
+
+// windowResponse is one window's parsed AI response and the inference that recorded it
+type windowResponse struct {
+	Response    map[string]interface{} `json:"response"`
+	InferenceID *int64                 `json:"inferenceId,omitempty"` // Nil if the inference couldn't be saved
+}
+
+// queryWindow asks the AI service about one window, keeping the ID of the inference row the query saved.
+// Models that support it are held to the prompt's response shape with a JSON Schema.
+func queryWindow(ctx context.Context, aiService *AIService, prompt string, analysisPrompt prompts.AnalysisPrompt, model string) (windowResponse, error) {
+	var result windowResponse
+
+	response, err := aiService.Query(ctx, prompt, &QueryOptions{
+		Model:          model,
+		ResponseSchema: analysisResponseSchema(analysisPrompt, model),
+		Callback: func(inference *models.Inference) {
+			if inference.ID != 0 {
+				inferenceID := inference.ID
+				result.InferenceID = &inferenceID
 			}
-			if breed, ok := patientData["possibleBreed"].(string); ok && breed != "" {
-				if patient.PossibleBreed == nil {
-					patient.PossibleBreed = &[]string{}
-				}
+		},
+	})
+	if err != nil {
+		return windowResponse{}, err
+	}
 
-				newPossibleBreed := true
+	result.Response = response
+	return result, nil
+}
 
-				for _, possibleBreed := range *patient.PossibleBreed {
-					if possibleBreed == breed {
-						newPossibleBreed = false
-					}
-				}
+// newDocuments returns the documents in current whose span wasn't in previous
+func newDocuments(previous []models.AnalyzedDocument, current []models.AnalyzedDocument) []models.AnalyzedDocument {
+	var added []models.AnalyzedDocument
 
-				if newPossibleBreed {
-					*patient.PossibleBreed = append(*patient.PossibleBreed, breed)
-				}
+	for _, doc := range current {
+		isNew := true
+		for _, existingDoc := range previous {
+			if existingDoc.StartLine == doc.StartLine && existingDoc.EndLine == doc.EndLine {
+				isNew = false
+				break
 			}
-			if sex, ok := patientData["sex"].(string); ok && sex != "" {
-				patient.Sex = &sex
+		}
+		if isNew {
+			added = append(added, doc)
+		}
+	}
+
+	return added
+}
+
+func notifyWindowComplete(opts *AnalyzeOptions, windowIndex int, windows []utils.Window, newDocuments []models.AnalyzedDocument, patients []*models.Patient, validationErrors []DocumentValidationError) {
+	if opts.OnWindowComplete == nil {
+		return
+	}
+
+	// Copies, so a listener holding on to the progress doesn't see later windows' merges
+	patientSnapshot := make([]models.Patient, len(patients))
+	for i, patient := range patients {
+		patientSnapshot[i] = *patient
+	}
+
+	window := windows[windowIndex]
+	opts.OnWindowComplete(WindowProgress{
+		WindowIndex:      windowIndex,
+		TotalWindows:     len(windows),
+		StartLine:        window.StartIndex + 1,
+		EndLine:          window.StartIndex + len(window.WindowLines),
+		NewDocuments:     newDocuments,
+		Patients:         patientSnapshot,
+		ValidationErrors: validationErrors,
+	})
+}
+
+// buildWindowPrompt numbers the window's lines and, when there are earlier findings, adds the incremental notice
+func buildWindowPrompt(analysisPrompt prompts.AnalysisPrompt, window utils.Window, patients []*models.Patient, analyzedDocuments []models.AnalyzedDocument, boundaryHints []BoundaryCandidate) string {
+	var promptBuilder strings.Builder
+	promptBuilder.WriteString(analysisPrompt.Base)
+
+	// Build incremental notice if we have previous documents
+	// This tells OpenAI what we've already found in earlier windows to avoid duplicates
+	if len(analyzedDocuments) > 0 {
+		summaries := make([]documentSummary, len(analyzedDocuments))
+		for i, doc := range analyzedDocuments {
+			summaries[i] = documentSummary{Title: doc.Title, StartLine: doc.StartLine, EndLine: doc.EndLine}
+			if analysisPrompt.MultiPatient && doc.PatientIndex >= 0 && doc.PatientIndex < len(patients) {
+				summaries[i].Patient = patients[doc.PatientIndex].Name
 			}
 		}
 
-		if docs, ok := response["documents"].([]interface{}); ok {
-			for _, doc := range docs {
-				if docDetails, ok := doc.(map[string]interface{}); ok {
-					// Check if this document already exists (deduplicate by start_line)
-					startLine := int64(docDetails["start_line"].(float64))
-					title := docDetails["title"].(string)
-					endLine := int64(docDetails["end_line"].(float64))
-					numberOfLines := endLine - startLine
-
-					isDuplicate := false
-					for _, existingDoc := range analyzedDocuments {
-						if existingDoc.StartLine == startLine {
-							isDuplicate = true
-							break
-						}
-					}
-
-					windowStartLine := startLine - int64(window.StartIndex)
-					windowEndLine := endLine - int64(window.StartIndex)
-
-					if !isDuplicate {
-						analyzedDocuments = append(analyzedDocuments, models.AnalyzedDocument{
-							Title:         title,
-							StartLine:     startLine,
-							EndLine:       endLine,
-							NumberOfLines: numberOfLines,
-							WindowLines:   window.WindowLines[windowStartLine:windowEndLine],
-						})
-					}
-				}
+		// Single-patient prompts were written for one patient object, not a list
+		var patientJSON []byte
+		if analysisPrompt.MultiPatient {
+			patientJSON, _ = json.MarshalIndent(patients, "  ", "  ")
+		} else {
+			patient := &models.Patient{}
+			if len(patients) > 0 {
+				patient = patients[0]
 			}
+			patientJSON, _ = json.MarshalIndent(patient, "  ", "  ")
+		}
+		docsJSON, _ := json.MarshalIndent(summaries, "  ", "  ")
+		incrementalNotice := fmt.Sprintf(analysisPrompt.IncrementalNotice, patientJSON, docsJSON)
+
+		promptBuilder.WriteString("\n")
+
+		promptBuilder.WriteString(incrementalNotice)
+
+		promptBuilder.WriteString("\n")
+	}
+
+	// Only the hints that fall inside this window are useful to the model
+	promptBuilder.WriteString(boundaryHintsBlock(boundaryHints, window.StartIndex+1, window.StartIndex+len(window.WindowLines)))
+
+	promptBuilder.WriteString("Here is the text chunk:\n")
+
+	for lineIndex, line := range window.WindowLines {
+		lineNumber := window.StartIndex + lineIndex + 1
+		promptBuilder.WriteString(fmt.Sprintf("%d: %s\n", lineNumber, line))
+	}
+
+	return promptBuilder.String()
+}
+
+func boundaryHintsBlock(boundaryHints []BoundaryCandidate, firstLine int, lastLine int) string {
+	var windowHints strings.Builder
+	for _, hint := range boundaryHints {
+		if hint.StartLine >= int64(firstLine) && hint.StartLine <= int64(lastLine) {
+			windowHints.WriteString(fmt.Sprintf("  %d: %s\n", hint.StartLine, hint.Title))
 		}
 	}
+	if windowHints.Len() == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(prompts.BoundaryHintsTemplate, windowHints.String()) + "\n"
+}
+
+/*
+promptOverheadTokens estimates everything in a window prompt except its numbered lines. The
+window's end isn't known yet, so it counts the boundary hints for the rest of the file; that
+overestimates a little, which only makes windows slightly smaller.
+*/
+func promptOverheadTokens(analysisPrompt prompts.AnalysisPrompt, startIndex int, fileLines []string, patients []*models.Patient, analyzedDocuments []models.AnalyzedDocument, boundaryHints []BoundaryCandidate) int {
+	prompt := buildWindowPrompt(analysisPrompt, utils.Window{StartIndex: startIndex}, patients, analyzedDocuments, nil)
+	hints := boundaryHintsBlock(boundaryHints, startIndex+1, len(fileLines))
+
+	return utils.EstimateTokens(prompt) + utils.EstimateTokens(hints)
+}
+
+/*
+mergeWindowResponse folds one window's patients into the running set and adds its documents as
+candidates, each pointing at the patient it names. Single-patient prompts answer with one
+"patient" object, which every document of the window belongs to.
+
+Documents the model got wrong (missing fields, lines outside the window) are skipped and
+returned as validation errors; a response that can't be decoded at all skips the whole window.
+*/
+func mergeWindowResponse(patients *patientSet, candidates []documentCandidate, window utils.Window, windowIndex int, result windowResponse) ([]documentCandidate, []DocumentValidationError) {
+	response, err := decodeAnalysisResponse(result.Response)
+	if err != nil {
+		return candidates, []DocumentValidationError{{WindowIndex: windowIndex, DocumentIndex: -1, Reason: err.Error()}}
+	}
+
+	origin := fieldOrigin{Window: window, WindowIndex: &windowIndex, InferenceID: result.InferenceID}
+
+	windowPatientData := response.Patients
+	if response.Patient != nil {
+		windowPatientData = append(windowPatientData, *response.Patient)
+	}
+
+	var windowPatients []int
+	for _, patientData := range windowPatientData {
+		if index := patients.merge(patientData, origin); index != -1 {
+			windowPatients = append(windowPatients, index)
+		}
+	}
+
+	var validationErrors []DocumentValidationError
+
+	for documentIndex, doc := range response.Documents {
+		if reason := doc.validate(window); reason != "" {
+			validationErrors = append(validationErrors, DocumentValidationError{
+				WindowIndex:   windowIndex,
+				DocumentIndex: documentIndex,
+				Title:         string(doc.Title),
+				StartLine:     int64(doc.StartLine),
+				EndLine:       int64(doc.EndLine),
+				Reason:        reason,
+			})
+			continue
+		}
+
+		startLine := int64(doc.StartLine)
+		endLine := int64(doc.EndLine)
+
+		patientIndex := patients.indexOf(string(doc.Patient))
+		if patientIndex == -1 && len(windowPatients) == 1 {
+			patientIndex = windowPatients[0]
+		}
+
+		// Line numbers in the prompt are 1-based and end_line is inclusive
+		windowFirstLine := int64(window.StartIndex + 1)
+		windowLastLine := int64(window.StartIndex + len(window.WindowLines))
+
+		candidates = append(candidates, documentCandidate{
+			Title:        string(doc.Title),
+			StartLine:    startLine,
+			EndLine:      endLine,
+			WindowIndex:  windowIndex,
+			CenterMargin: min(startLine-windowFirstLine, windowLastLine-endLine),
+			PatientIndex: patientIndex,
+		})
+	}
 
-	return &patient, analyzedDocuments, nil
+	return candidates, validationErrors
 }
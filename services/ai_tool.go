@@ -9,6 +9,7 @@ import (
 
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/option"
+	"github.com/openai/openai-go/v2/shared"
 
 	"PennieAI/config"
 	"PennieAI/models"
@@ -23,6 +24,16 @@ type QueryOptions struct {
 	Schema    func(map[string]interface{}) error // Validation function
 	Inferable interface{}                        // Object to link inference to
 	Callback  func(*models.Inference)            // Block/yield equivalent
+	Model     string                             // Overrides OPENAI_MODEL_VERSION when set
+	// ResponseSchema constrains the response to a JSON Schema (structured outputs) instead of
+	// only asking for JSON in the system message. The model must support it, see SupportsStructuredOutputs.
+	ResponseSchema *ResponseSchema
+}
+
+// ResponseSchema is a named JSON Schema for structured outputs, usually built with utils.JSONSchemaFor
+type ResponseSchema struct {
+	Name   string
+	Schema map[string]interface{}
 }
 
 func NewAIService() *AIService {
@@ -44,24 +55,49 @@ func (s *AIService) Query(ctx context.Context, prompt string, opts *QueryOptions
 		opts = &QueryOptions{}
 	}
 
-	model := os.Getenv("OPENAI_MODEL_VERSION")
+	model := GetModelVersion()
+	if opts.Model != "" {
+		model = opts.Model
+	}
 
-	response, err := s.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+	params := openai.ChatCompletionNewParams{
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.SystemMessage("You are a helpful assistant for a veterinary healthcare company, Pennie. Please respond with valid JSON."),
 			openai.UserMessage(prompt),
 		},
-		Model: os.Getenv("OPENAI_MODEL_VERSION"),
-	})
+		Model: model,
+	}
+	inferenceConfig := map[string]interface{}{
+		"model":           model,
+		"response_format": "json",
+	}
+
+	if opts.ResponseSchema != nil {
+		// Strict mode makes the model follow the schema exactly rather than treating it as a hint
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   opts.ResponseSchema.Name,
+					Schema: opts.ResponseSchema.Schema,
+					Strict: openai.Bool(true),
+				},
+			},
+		}
+		inferenceConfig["response_format"] = "json_schema"
+		inferenceConfig["json_schema"] = map[string]interface{}{
+			"name":   opts.ResponseSchema.Name,
+			"strict": true,
+			"schema": opts.ResponseSchema.Schema,
+		}
+	}
+
+	response, err := s.client.Chat.Completions.New(ctx, params)
 
 	// Create inference record (equivalent to Inference.create!)
 	inference := &models.Inference{
-		Request:  prompt,
-		Response: "", // Will be set after processing response
-		Config: map[string]interface{}{
-			"model":           model,
-			"response_format": "json",
-		},
+		Request:       prompt,
+		Response:      "", // Will be set after processing response
+		Config:        inferenceConfig,
 		InferableType: nil,
 		InferableID:   nil,
 		CreatedAt:     time.Now(),
@@ -69,19 +105,18 @@ func (s *AIService) Query(ctx context.Context, prompt string, opts *QueryOptions
 	}
 
 	// Handle linking to inferable object (polymorphic association)
-	//if opts.Inferable != nil {
-	//	// Maybe implement polymorphic logic here based on type
-	//	// For now, simplified:
-	//	switch v := opts.Inferable.(type) {
-	//	case *models.UnprocessedDocument:
-	//		inference.InferableType = stringPtr("Document")
-	//		inference.InferableID = &v.ID
-	//	case *models.Patient:
-	//		inference.InferableType = stringPtr("Patient")
-	//		id := int64(v.ID)
-	//		inference.InferableID = &id
-	//	}
-	//}
+	switch v := opts.Inferable.(type) {
+	case *models.AnalyzedDocument:
+		inferableType := "AnalyzedDocument"
+		inferableID := v.ID
+		inference.InferableType = &inferableType
+		inference.InferableID = &inferableID
+	case *models.Patient:
+		inferableType := "Patient"
+		inferableID := int64(v.ID)
+		inference.InferableType = &inferableType
+		inference.InferableID = &inferableID
+	}
 
 	if err != nil {
 		// Log failed inference
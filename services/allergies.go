@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// allergiesResponse is the shape prompts.AllergyExtractionPrompt asks for
+type allergiesResponse struct {
+	Allergies []allergyResponse `json:"allergies"`
+}
+
+type allergyResponse struct {
+	Allergen  lenientString `json:"allergen"`
+	Kind      lenientString `json:"kind" enum:"allergy,adverse_reaction"`
+	Category  lenientString `json:"category" enum:"drug,vaccine,food,environmental,other"`
+	Reaction  lenientString `json:"reaction" description:"what happened, e.g. hives and facial swelling; empty if not stated"`
+	Severity  lenientString `json:"severity" enum:"mild,moderate,severe,unknown"`
+	StartLine lenientInt    `json:"start_line" description:"first line that records the allergy"`
+	EndLine   lenientInt    `json:"end_line" description:"last line that records the allergy"`
+}
+
+var allergiesSchema = &ResponseSchema{
+	Name:   "allergies",
+	Schema: utils.JSONSchemaFor(allergiesResponse{}),
+}
+
+var allergyCategories = map[string]bool{
+	models.AllergyCategoryDrug:          true,
+	models.AllergyCategoryVaccine:       true,
+	models.AllergyCategoryFood:          true,
+	models.AllergyCategoryEnvironmental: true,
+	models.AllergyCategoryOther:         true,
+}
+
+// ExtractAllergies asks the AI service which allergies and adverse reactions a saved document
+// records and stores them for the document's patient, replacing what an earlier extraction found
+func ExtractAllergies(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	var decoded allergiesResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.AllergyExtractionPrompt, allergiesSchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var allergies []models.Allergy
+	for _, item := range decoded.Allergies {
+		allergen := strings.TrimSpace(string(item.Allergen))
+		if allergen == "" {
+			continue
+		}
+
+		kind := models.AllergyKindAllergy
+		if strings.EqualFold(strings.TrimSpace(string(item.Kind)), models.AllergyKindAdverseReaction) {
+			kind = models.AllergyKindAdverseReaction
+		}
+		category := strings.ToLower(strings.TrimSpace(string(item.Category)))
+		if !allergyCategories[category] {
+			category = models.AllergyCategoryOther
+		}
+		severity := strings.ToLower(strings.TrimSpace(string(item.Severity)))
+		if !models.IsAllergySeverity(severity) {
+			severity = models.AllergySeverityUnknown
+		}
+
+		allergy := models.Allergy{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			Allergen:           allergen,
+			Kind:               kind,
+			Category:           category,
+			Reaction:           optionalString(item.Reaction),
+			Severity:           severity,
+			InferenceID:        inferenceID,
+		}
+		allergy.StartLine, allergy.EndLine, allergy.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		allergies = append(allergies, allergy)
+	}
+
+	return repository.ReplaceDocumentAllergies(document.ID, allergies)
+}
+
+// MergeAllergies keeps one record per allergen, the most severe one; several documents often
+// mention the same allergy. The order of allergies is kept.
+func MergeAllergies(allergies []models.Allergy) []models.Allergy {
+	merged := []models.Allergy{}
+	index := map[string]int{}
+
+	for _, allergy := range allergies {
+		key := stemTerm(allergy.Allergen)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, allergy)
+			continue
+		}
+		if allergy.MoreSevereThan(merged[i]) {
+			merged[i] = allergy
+		}
+	}
+
+	return merged
+}
+
+// AttachAllergies sets each patient's merged allergies, so they come with every patient response
+func AttachAllergies(patients []*models.Patient) error {
+	if len(patients) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(patients))
+	for i, patient := range patients {
+		ids[i] = patient.ID
+	}
+
+	allergies, err := repository.GetAllergiesByPatientIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	byPatient := map[int][]models.Allergy{}
+	for _, allergy := range allergies {
+		byPatient[int(allergy.PatientID)] = append(byPatient[int(allergy.PatientID)], allergy)
+	}
+	for _, patient := range patients {
+		patient.Allergies = MergeAllergies(byPatient[patient.ID])
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"strings"
+
+	"PennieAI/models"
+)
+
+/*
+drugClassMembers lists, per drug class an allergy may name, the drugs and brand names that
+belong to it, so an allergy to "penicillins" flags amoxicillin. Keys are in the form
+stemTerm gives them; members are stemmed when they are compared.
+*/
+var drugClassMembers = map[string][]string{
+	"penicillin":      {"amoxicillin", "ampicillin", "clavamox", "augmentin", "penicillin g", "ticarcillin"},
+	"beta lactam":     {"amoxicillin", "ampicillin", "clavamox", "cephalexin", "cefpodoxime", "cefovecin", "convenia", "simplicef"},
+	"cephalosporin":   {"cephalexin", "cefazolin", "cefpodoxime", "cefovecin", "convenia", "simplicef"},
+	"sulfa":           {"sulfamethoxazole", "sulfadiazine", "sulfadimethoxine", "albon", "trimethoprim sulfa", "tmp sms"},
+	"sulfonamide":     {"sulfamethoxazole", "sulfadiazine", "sulfadimethoxine", "albon", "trimethoprim sulfa", "tmp sms"},
+	"nsaid":           {"carprofen", "rimadyl", "meloxicam", "metacam", "deracoxib", "deramaxx", "firocoxib", "previcox", "robenacoxib", "onsior", "grapiprant", "galliprant"},
+	"fluoroquinolone": {"enrofloxacin", "baytril", "marbofloxacin", "zeniquin", "pradofloxacin", "veraflox", "orbifloxacin"},
+	"tetracycline":    {"doxycycline", "minocycline", "oxytetracycline"},
+	"opioid":          {"buprenorphine", "butorphanol", "hydromorphone", "methadone", "morphine", "fentanyl", "tramadol"},
+	"corticosteroid":  {"prednisone", "prednisolone", "dexamethasone", "methylprednisolone", "triamcinolone"},
+	"steroid":         {"prednisone", "prednisolone", "dexamethasone", "methylprednisolone", "triamcinolone"},
+}
+
+// Words an allergen is often written with that don't name the substance
+var allergenFillerWords = map[string]bool{
+	"allergy": true, "allergic": true, "to": true, "class": true, "drug": true, "drugs": true,
+	"antibiotic": true, "antibiotics": true, "reaction": true,
+}
+
+/*
+AllergyConflicts returns the allergens of a patient's drug allergies and reactions (including
+unspecified ones) that a medication matches: the medication's name contains the allergen, or the
+allergen names a drug class the medication belongs to.
+*/
+func AllergyConflicts(medication models.Medication, allergies []models.Allergy) []string {
+	name := " " + stemTerm(medication.Name) + " "
+
+	conflicts := []string{}
+	for _, allergy := range allergies {
+		if allergy.Category != models.AllergyCategoryDrug && allergy.Category != models.AllergyCategoryOther {
+			continue
+		}
+
+		allergen := normalizeAllergen(allergy.Allergen)
+		if allergen == "" {
+			continue
+		}
+		if strings.Contains(name, " "+allergen+" ") || medicationInDrugClass(name, allergen) {
+			conflicts = append(conflicts, allergy.Allergen)
+		}
+	}
+	return conflicts
+}
+
+// normalizeAllergen reduces "Penicillins (allergy)" to "penicillin", stemmed like medication names
+func normalizeAllergen(allergen string) string {
+	var words []string
+	for _, word := range strings.Fields(normalizeTerm(allergen)) {
+		if !allergenFillerWords[word] {
+			words = append(words, word)
+		}
+	}
+	return stemTerm(strings.Join(words, " "))
+}
+
+func medicationInDrugClass(paddedName string, allergen string) bool {
+	for _, member := range drugClassMembers[allergen] {
+		if strings.Contains(paddedName, " "+stemTerm(member)+" ") {
+			return true
+		}
+	}
+	return false
+}
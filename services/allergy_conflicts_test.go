@@ -0,0 +1,48 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"PennieAI/models"
+)
+
+func TestAllergyConflicts(t *testing.T) {
+	drugAllergy := func(allergen string) models.Allergy {
+		return models.Allergy{Allergen: allergen, Category: models.AllergyCategoryDrug}
+	}
+
+	tests := []struct {
+		name       string
+		medication string
+		allergies  []models.Allergy
+		want       []string
+	}{
+		{name: "same drug", medication: "Amoxicillin 250 mg", allergies: []models.Allergy{drugAllergy("amoxicillin")}, want: []string{"amoxicillin"}},
+		{name: "drug class", medication: "Amoxicillin", allergies: []models.Allergy{drugAllergy("Penicillins")}, want: []string{"Penicillins"}},
+		{name: "drug class with filler words", medication: "Clavamox", allergies: []models.Allergy{drugAllergy("penicillin drugs (allergy)")}, want: []string{"penicillin drugs (allergy)"}},
+		{name: "abbreviated class", medication: "Carprofen (Rimadyl)", allergies: []models.Allergy{drugAllergy("NSAIDs")}, want: []string{"NSAIDs"}},
+		{name: "brand name in class", medication: "Convenia injection", allergies: []models.Allergy{drugAllergy("Cephalosporins")}, want: []string{"Cephalosporins"}},
+		{name: "other category counts", medication: "Meloxicam", allergies: []models.Allergy{{Allergen: "NSAID", Category: models.AllergyCategoryOther}}, want: []string{"NSAID"}},
+		{
+			name:       "several allergies",
+			medication: "Trimethoprim sulfa",
+			allergies:  []models.Allergy{drugAllergy("Sulfonamides"), drugAllergy("Penicillins"), drugAllergy("sulfa")},
+			want:       []string{"Sulfonamides", "sulfa"},
+		},
+		{name: "unrelated drug", medication: "Prednisone", allergies: []models.Allergy{drugAllergy("Penicillins")}, want: []string{}},
+		{name: "part of a word isn't a match", medication: "Cefpodoxime", allergies: []models.Allergy{drugAllergy("pod")}, want: []string{}},
+		{name: "food allergy never conflicts", medication: "Chicken flavored chews", allergies: []models.Allergy{{Allergen: "chicken", Category: models.AllergyCategoryFood}}, want: []string{}},
+		{name: "only filler words", medication: "Amoxicillin", allergies: []models.Allergy{drugAllergy("drug allergy")}, want: []string{}},
+		{name: "no allergies", medication: "Amoxicillin", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllergyConflicts(models.Medication{Name: tt.medication}, tt.allergies)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllergyConflicts(%q) = %v, want %v", tt.medication, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"PennieAI/config"
+)
+
+const (
+	checkpointKeyPrefix = "checkpoints:analysis" // checkpoints:analysis:<hash> is a Redis hash of window index -> response JSON
+	checkpointTTL       = 24 * time.Hour         // A failed run can be resumed for this long
+)
+
+/*
+Checkpoints store each window's parsed AI response (and its inference ID) as soon as it arrives. Replaying the stored
+responses through mergeWindowResponse rebuilds exactly the patient and documents the failed
+run had accumulated, so a resumed run only pays for the windows that never finished.
+
+The key is a hash of the file content and every setting that changes the responses, so
+re-submitting the same upload with the same settings picks up where the last attempt stopped.
+Checkpointing is best effort: Redis errors are logged and the analysis carries on without it.
+*/
+func checkpointKey(fileLines []string, settings AnalysisSettings) string {
+	hash := sha256.New()
+	hash.Write([]byte(strings.Join(fileLines, "\n")))
+	fmt.Fprintf(hash, "|%s|%s|%d|%d|%d|%d|%t|%t", settings.Model, settings.PromptVersion, settings.WindowSize, settings.overlapSize(), settings.TokenBudget, settings.SnapTolerance, settings.Parallel, settings.BoundaryHints)
+
+	return fmt.Sprintf("%s:%s", checkpointKeyPrefix, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// loadCheckpoint returns the stored responses by window index, or an empty map if there are none
+func loadCheckpoint(ctx context.Context, key string) map[int]windowResponse {
+	responses := map[int]windowResponse{}
+
+	stored, err := config.GetRedis().HGetAll(ctx, key).Result()
+	if err != nil {
+		log.Printf("⚠️  Failed to load analysis checkpoint: %v", err)
+		return responses
+	}
+
+	for field, value := range stored {
+		windowIndex, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		var response windowResponse
+		if err := json.Unmarshal([]byte(value), &response); err != nil {
+			continue
+		}
+		if response.Response == nil {
+			// Checkpoints saved before inference IDs were kept hold the bare response
+			if err := json.Unmarshal([]byte(value), &response.Response); err != nil {
+				continue
+			}
+		}
+		responses[windowIndex] = response
+	}
+
+	return responses
+}
+
+func saveCheckpoint(ctx context.Context, key string, windowIndex int, response windowResponse) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("⚠️  Failed to encode checkpoint for window %d: %v", windowIndex, err)
+		return
+	}
+
+	rdb := config.GetRedis()
+	pipe := rdb.TxPipeline()
+	pipe.HSet(ctx, key, strconv.Itoa(windowIndex), responseJSON)
+	pipe.Expire(ctx, key, checkpointTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to save checkpoint for window %d: %v", windowIndex, err)
+	}
+}
+
+// ClearAnalysisCheckpoint drops the checkpoint once a run has been saved and no longer needs resuming
+func ClearAnalysisCheckpoint(fileLines []string, settings AnalysisSettings) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := config.GetRedis().Del(ctx, checkpointKey(fileLines, settings)).Err(); err != nil {
+		log.Printf("⚠️  Failed to clear analysis checkpoint: %v", err)
+	}
+}
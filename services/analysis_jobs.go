@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"PennieAI/config"
+	"PennieAI/models"
+)
+
+const (
+	analysisQueueKey         = "jobs:analysis:queue"      // Redis list of job IDs waiting for a worker
+	analysisProcessingPrefix = "jobs:analysis:processing" // <prefix>:<worker> lists the job IDs a worker has taken and not finished yet
+	analysisLeasePrefix      = "jobs:analysis:lease"      // <prefix>:<worker> exists while the worker's process is alive
+	analysisWorkersKey       = "jobs:analysis:workers"    // Set of every worker that may still have a processing list
+	analysisJobKeyPrefix     = "jobs:analysis"            // jobs:analysis:<id> holds the job status JSON
+	analysisJobTTL           = 24 * time.Hour             // Finished jobs are kept around for polling this long
+	workerPollTimeout        = 5 * time.Second            // How long BLMOVE blocks before checking again
+	workerHeartbeatInterval  = 10 * time.Second           // How often a process renews its workers' leases
+	workerLeaseTTL           = 30 * time.Second           // A lease not renewed for this long marks its worker as stopped
+	defaultWorkerCount       = 2
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+func analysisJobKey(jobID string) string {
+	return fmt.Sprintf("%s:%s", analysisJobKeyPrefix, jobID)
+}
+
+// The uploaded lines are stored apart from the status so polling doesn't have to load the whole file
+func analysisPayloadKey(jobID string) string {
+	return fmt.Sprintf("%s:%s:payload", analysisJobKeyPrefix, jobID)
+}
+
+// EnqueueAnalysis stores the request in Redis and pushes it onto the queue for a worker to pick up
+func EnqueueAnalysis(ctx context.Context, request AnalysisRequest) (*models.AnalysisJob, error) {
+	rdb := config.GetRedis()
+
+	now := time.Now()
+	job := &models.AnalysisJob{
+		ID:        uuid.NewString(),
+		Status:    models.JobStatusQueued,
+		DoctorID:  request.DoctorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	jobJSON, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	// Write the job and payload before queueing so a worker never pops an ID it can't load
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, analysisPayloadKey(job.ID), payload, analysisJobTTL)
+	pipe.Set(ctx, analysisJobKey(job.ID), jobJSON, analysisJobTTL)
+	pipe.LPush(ctx, analysisQueueKey, job.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetAnalysisJob loads the current status of a job
+func GetAnalysisJob(ctx context.Context, jobID string) (*models.AnalysisJob, error) {
+	rdb := config.GetRedis()
+
+	jobJSON, err := rdb.Get(ctx, analysisJobKey(jobID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	var job models.AnalysisJob
+	if err := json.Unmarshal(jobJSON, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+
+	return &job, nil
+}
+
+var ErrJobNotRetryable = errors.New("only failed jobs can be retried")
+
+/*
+RetryAnalysisJob puts a failed job back on the queue. Its payload is kept on failure, and the
+windows that completed before the failure are restored from their checkpoint, so the retry
+only queries the windows that are left. The status check and the requeue run in one WATCH
+transaction, so when two retries race only one of them queues the job.
+*/
+func RetryAnalysisJob(ctx context.Context, job *models.AnalysisJob) error {
+	rdb := config.GetRedis()
+	jobKey := analysisJobKey(job.ID)
+
+	err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+		jobJSON, err := tx.Get(ctx, jobKey).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return ErrJobNotFound
+			}
+			return err
+		}
+
+		var current models.AnalysisJob
+		if err := json.Unmarshal(jobJSON, &current); err != nil {
+			return fmt.Errorf("failed to decode job: %w", err)
+		}
+		if current.Status != models.JobStatusFailed {
+			return ErrJobNotRetryable
+		}
+
+		exists, err := tx.Exists(ctx, analysisPayloadKey(job.ID)).Result()
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			return errors.New("job payload has expired, upload the document again")
+		}
+
+		current.Status = models.JobStatusQueued
+		current.Error = ""
+		current.Attempts++
+		current.UpdatedAt = time.Now()
+		queuedJSON, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		// Refresh the payload TTL so it outlives the retried run. Exec fails with
+		// redis.TxFailedErr if the job changed since the GET above.
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, jobKey, queuedJSON, analysisJobTTL)
+			pipe.Expire(ctx, analysisPayloadKey(job.ID), analysisJobTTL)
+			pipe.LPush(ctx, analysisQueueKey, job.ID)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		*job = current
+		return nil
+	}, jobKey)
+
+	// Another request changed the job first, most likely a concurrent retry that already queued it
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrJobNotRetryable
+	}
+	return err
+}
+
+func saveAnalysisJob(ctx context.Context, job *models.AnalysisJob) error {
+	job.UpdatedAt = time.Now()
+
+	jobJSON, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return config.GetRedis().Set(ctx, analysisJobKey(job.ID), jobJSON, analysisJobTTL).Err()
+}
+
+/*
+StartAnalysisWorkers launches workerCount goroutines that pull job IDs off the Redis queue
+and run them one at a time. Workers run for the lifetime of the process. Each worker has its
+own processing list guarded by a lease this process keeps refreshing, so other instances can
+tell a live worker's jobs from ones a stopped process left behind and requeue only the latter.
+*/
+func StartAnalysisWorkers(workerCount int) {
+	if workerCount <= 0 {
+		workerCount = defaultWorkerCount
+	}
+
+	ctx := context.Background()
+	instanceID := uuid.NewString()
+
+	workerKeys := make([]string, workerCount)
+	for i := range workerKeys {
+		workerKeys[i] = fmt.Sprintf("%s:%d", instanceID, i)
+	}
+
+	// Take the leases before the first BLMOVE so no other instance mistakes these lists for abandoned ones
+	if err := renewWorkerLeases(ctx, workerKeys); err != nil {
+		log.Printf("⚠️  Failed to register analysis workers: %v", err)
+	}
+	if err := requeueInterruptedJobs(ctx); err != nil {
+		log.Printf("⚠️  Failed to requeue interrupted analysis jobs: %v", err)
+	}
+
+	go workerHeartbeat(ctx, workerKeys)
+	for i, workerKey := range workerKeys {
+		go analysisWorker(i, workerKey)
+	}
+
+	log.Printf("✅ Started %d analysis workers", workerCount)
+}
+
+func analysisProcessingKey(workerKey string) string {
+	return fmt.Sprintf("%s:%s", analysisProcessingPrefix, workerKey)
+}
+
+func analysisLeaseKey(workerKey string) string {
+	return fmt.Sprintf("%s:%s", analysisLeasePrefix, workerKey)
+}
+
+// renewWorkerLeases registers the workers and pushes their lease expiry forward
+func renewWorkerLeases(ctx context.Context, workerKeys []string) error {
+	pipe := config.GetRedis().TxPipeline()
+	for _, workerKey := range workerKeys {
+		pipe.SAdd(ctx, analysisWorkersKey, workerKey)
+		pipe.Set(ctx, analysisLeaseKey(workerKey), time.Now().Format(time.RFC3339), workerLeaseTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+/*
+workerHeartbeat keeps this process's leases alive and, on the same tick, requeues the jobs of
+workers whose lease has run out. That way jobs of an instance that stopped are picked up by the
+instances still running, not only by the next one to start.
+*/
+func workerHeartbeat(ctx context.Context, workerKeys []string) {
+	ticker := time.NewTicker(workerHeartbeatInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := renewWorkerLeases(ctx, workerKeys); err != nil {
+			log.Printf("⚠️  Failed to renew analysis worker leases: %v", err)
+		}
+		if err := requeueInterruptedJobs(ctx); err != nil {
+			log.Printf("⚠️  Failed to requeue interrupted analysis jobs: %v", err)
+		}
+	}
+}
+
+func analysisWorker(workerID int, workerKey string) {
+	rdb := config.GetRedis()
+	ctx := context.Background()
+	processingKey := analysisProcessingKey(workerKey)
+
+	for {
+		// The job ID moves to this worker's processing list in the same step it leaves the queue, so
+		// a job is never lost if the process stops while running it. redis.Nil means nothing was queued.
+		jobID, err := rdb.BLMove(ctx, analysisQueueKey, processingKey, "RIGHT", "LEFT", workerPollTimeout).Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				log.Printf("⚠️  Analysis worker %d failed to read queue: %v", workerID, err)
+				time.Sleep(workerPollTimeout)
+			}
+			continue
+		}
+
+		if err := processAnalysisJob(ctx, jobID); err != nil {
+			log.Printf("⚠️  Analysis worker %d failed job %s: %v", workerID, jobID, err)
+		}
+		if err := rdb.LRem(ctx, processingKey, 1, jobID).Err(); err != nil {
+			log.Printf("⚠️  Analysis worker %d failed to clear job %s from the processing list: %v", workerID, jobID, err)
+		}
+	}
+}
+
+/*
+requeueInterruptedJobs moves the jobs on the processing lists of workers whose lease has expired
+back onto the queue. Lists of live workers, in this or any other instance, are left alone. Jobs
+are popped one at a time, so when two instances reap the same list each job is requeued once.
+Jobs whose status has expired are dropped; ones whose payload has expired fail.
+*/
+func requeueInterruptedJobs(ctx context.Context) error {
+	rdb := config.GetRedis()
+
+	workerKeys, err := rdb.SMembers(ctx, analysisWorkersKey).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, workerKey := range workerKeys {
+		alive, err := rdb.Exists(ctx, analysisLeaseKey(workerKey)).Result()
+		if err != nil {
+			return err
+		}
+		if alive > 0 {
+			continue
+		}
+
+		if err := requeueWorkerJobs(ctx, analysisProcessingKey(workerKey)); err != nil {
+			return err
+		}
+		// Only forget the worker once its list is empty, a failure above leaves it for the next pass
+		if err := rdb.SRem(ctx, analysisWorkersKey, workerKey).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func requeueWorkerJobs(ctx context.Context, processingKey string) error {
+	rdb := config.GetRedis()
+
+	for {
+		// The right end is the oldest job the worker took
+		jobID, err := rdb.RPop(ctx, processingKey).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			return err
+		}
+
+		job, err := GetAnalysisJob(ctx, jobID)
+		if err != nil {
+			if errors.Is(err, ErrJobNotFound) {
+				continue
+			}
+			return err
+		}
+
+		exists, err := rdb.Exists(ctx, analysisPayloadKey(jobID)).Result()
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			jobErr := failAnalysisJob(ctx, job, errors.New("job was interrupted and its payload has expired, upload the document again"))
+			log.Printf("⚠️  Failed interrupted analysis job %s: %v", jobID, jobErr)
+			continue
+		}
+
+		job.Status = models.JobStatusQueued
+		if err := saveAnalysisJob(ctx, job); err != nil {
+			return err
+		}
+		// The right end is popped next, interrupted jobs go before newer ones
+		if err := rdb.RPush(ctx, analysisQueueKey, jobID).Err(); err != nil {
+			return err
+		}
+		log.Printf("Requeued interrupted analysis job %s", jobID)
+	}
+}
+
+func processAnalysisJob(ctx context.Context, jobID string) (err error) {
+	rdb := config.GetRedis()
+
+	job, err := GetAnalysisJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	// A panic in the analysis (e.g. on a malformed model response) fails the job instead of the process
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("⚠️  Analysis job %s panicked: %v\n%s", jobID, recovered, debug.Stack())
+			err = failAnalysisJob(ctx, job, fmt.Errorf("analysis panicked: %v", recovered))
+		}
+	}()
+
+	payload, err := rdb.Get(ctx, analysisPayloadKey(jobID)).Bytes()
+	if err != nil {
+		return failAnalysisJob(ctx, job, fmt.Errorf("failed to load job payload: %w", err))
+	}
+
+	var request AnalysisRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return failAnalysisJob(ctx, job, fmt.Errorf("failed to decode job payload: %w", err))
+	}
+
+	job.Status = models.JobStatusRunning
+	if err := saveAnalysisJob(ctx, job); err != nil {
+		return err
+	}
+
+	result, err := RunAnalysis(ctx, request, NewAIService(), &AnalyzeOptions{
+		OnWindowComplete: func(progress WindowProgress) {
+			job.WindowsCompleted = progress.WindowIndex + 1
+			job.TotalWindows = progress.TotalWindows
+			if err := saveAnalysisJob(ctx, job); err != nil {
+				log.Printf("⚠️  Failed to update progress for job %s: %v", job.ID, err)
+			}
+		},
+	})
+	if err != nil {
+		return failAnalysisJob(ctx, job, err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return failAnalysisJob(ctx, job, fmt.Errorf("failed to encode result: %w", err))
+	}
+
+	job.Status = models.JobStatusSucceeded
+	job.Result = resultJSON
+	job.ResumedWindows = result.ResumedWindows
+	if err := saveAnalysisJob(ctx, job); err != nil {
+		return err
+	}
+
+	// The file now lives in unprocessed_documents, no need to keep a second copy in Redis
+	return rdb.Del(ctx, analysisPayloadKey(jobID)).Err()
+}
+
+func failAnalysisJob(ctx context.Context, job *models.AnalysisJob, jobErr error) error {
+	job.Status = models.JobStatusFailed
+	job.Error = jobErr.Error()
+	if err := saveAnalysisJob(ctx, job); err != nil {
+		return fmt.Errorf("%v (and failed to record failure: %w)", jobErr, err)
+	}
+	return jobErr
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"PennieAI/utils"
+)
+
+/*
+AnalysisResponse is what the document analysis prompts ask the model to return. Single-patient
+prompts (v1) answer with Patient, multi-patient prompts with Patients.
+
+The model doesn't always follow the shape it's given, so scalar fields decode leniently: a
+number where a string was asked for (or the other way round) is accepted, and null is empty.
+A document that still can't be decoded is kept with its decode error and rejected by validate,
+so one bad document doesn't throw away the rest of the window.
+*/
+type AnalysisResponse struct {
+	Patient   *PatientResponse   `json:"patient,omitempty"`
+	Patients  []PatientResponse  `json:"patients,omitempty"`
+	Documents []DocumentResponse `json:"documents"`
+}
+
+type PatientResponse struct {
+	Name            lenientString `json:"name"`
+	PossibleSpecies lenientString `json:"possibleSpecies"`
+	PossibleBreed   lenientString `json:"possibleBreed"`
+	Sex             lenientString `json:"sex"`
+	DateOfBirth     lenientString `json:"date_of_birth" description:"date in yyyy-MM-dd format"`
+	Weight          lenientString `json:"weight"`
+	Height          lenientString `json:"height"`
+	Color           lenientString `json:"color"`
+}
+
+type DocumentResponse struct {
+	Title     lenientString `json:"title"`
+	StartLine lenientInt    `json:"start_line" description:"start of document"`                      // 1-based
+	EndLine   lenientInt    `json:"end_line" description:"end of document"`                          // 1-based, inclusive
+	Patient   lenientString `json:"patient" description:"name of the patient the document is about"` // Multi-patient prompts only
+
+	decodeError string
+}
+
+func (d *DocumentResponse) UnmarshalJSON(data []byte) error {
+	// documentFields has the same fields but not this method, so decoding into it doesn't recurse
+	type documentFields DocumentResponse
+	var fields documentFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		reason := err.Error()
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "" {
+			reason = fmt.Sprintf("expected a document object, got %s", typeErr.Value)
+		}
+		*d = DocumentResponse{decodeError: reason}
+		return nil
+	}
+	*d = DocumentResponse(fields)
+	return nil
+}
+
+// DocumentValidationError is a document (or a whole window response) that was left out because the model's output was unusable
+type DocumentValidationError struct {
+	WindowIndex   int    `json:"windowIndex"`
+	DocumentIndex int    `json:"documentIndex"` // Position in the window's documents, -1 when the whole response was rejected
+	Title         string `json:"title,omitempty"`
+	StartLine     int64  `json:"startLine,omitempty"`
+	EndLine       int64  `json:"endLine,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+// decodeAnalysisResponse converts a parsed AI response into its typed form
+func decodeAnalysisResponse(response map[string]interface{}) (AnalysisResponse, error) {
+	var decoded AnalysisResponse
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return decoded, err
+	}
+	if err := json.Unmarshal(responseJSON, &decoded); err != nil {
+		return decoded, fmt.Errorf("response doesn't match the expected shape: %w", err)
+	}
+
+	return decoded, nil
+}
+
+// validate returns why a document can't be used, or "" if it can. Lines must be inside the
+// window the model was shown, which is always inside the file.
+func (d DocumentResponse) validate(window utils.Window) string {
+	windowFirstLine := int64(window.StartIndex + 1)
+	windowLastLine := int64(window.StartIndex + len(window.WindowLines))
+
+	switch {
+	case d.decodeError != "":
+		return d.decodeError
+	case strings.TrimSpace(string(d.Title)) == "":
+		return "missing title"
+	case d.StartLine == 0 || d.EndLine == 0:
+		return "missing start_line or end_line"
+	case d.EndLine < d.StartLine:
+		return "end_line is before start_line"
+	case int64(d.StartLine) < windowFirstLine || int64(d.EndLine) > windowLastLine:
+		return fmt.Sprintf("lines are outside the window (%d-%d)", windowFirstLine, windowLastLine)
+	}
+	return ""
+}
+
+// lenientString accepts a JSON string, number or boolean; null, objects and arrays decode to ""
+type lenientString string
+
+func (s *lenientString) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch typed := value.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = lenientString(strings.TrimSpace(typed))
+	case float64, bool:
+		*s = lenientString(fmt.Sprint(typed))
+	default:
+		*s = ""
+	}
+	return nil
+}
+
+func (lenientString) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+// lenientInt accepts a JSON number or a numeric string; null decodes to 0
+type lenientInt int64
+
+func (i *lenientInt) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch typed := value.(type) {
+	case nil:
+		*i = 0
+	case float64:
+		if typed != float64(int64(typed)) {
+			return fmt.Errorf("expected a whole number, got %s", data)
+		}
+		*i = lenientInt(typed)
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(typed), 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected a whole number, got %s", data)
+		}
+		*i = lenientInt(parsed)
+	default:
+		return fmt.Errorf("expected a whole number, got %s", data)
+	}
+	return nil
+}
+
+func (lenientInt) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "integer"}
+}
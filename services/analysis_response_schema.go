@@ -0,0 +1,47 @@
+package services
+
+import (
+	"PennieAI/prompts"
+	"PennieAI/utils"
+)
+
+// singlePatientAnalysisResponse is the shape the single-patient prompts (v1) describe
+type singlePatientAnalysisResponse struct {
+	Patient   PatientResponse         `json:"patient"`
+	Documents []singlePatientDocument `json:"documents"`
+}
+
+type singlePatientDocument struct {
+	Title     lenientString `json:"title"`
+	StartLine lenientInt    `json:"start_line" description:"start of document"`
+	EndLine   lenientInt    `json:"end_line" description:"end of document"`
+}
+
+// multiPatientAnalysisResponse is the shape the multi-patient prompts (v2) describe
+type multiPatientAnalysisResponse struct {
+	Patients  []PatientResponse  `json:"patients"`
+	Documents []DocumentResponse `json:"documents"`
+}
+
+// Built once, the shapes never change while the server runs
+var (
+	singlePatientResponseSchema = &ResponseSchema{
+		Name:   "document_analysis",
+		Schema: utils.JSONSchemaFor(singlePatientAnalysisResponse{}),
+	}
+	multiPatientResponseSchema = &ResponseSchema{
+		Name:   "multi_patient_document_analysis",
+		Schema: utils.JSONSchemaFor(multiPatientAnalysisResponse{}),
+	}
+)
+
+// analysisResponseSchema is the structured output schema for a prompt's response, or nil if the model can't take one
+func analysisResponseSchema(analysisPrompt prompts.AnalysisPrompt, model string) *ResponseSchema {
+	if !SupportsStructuredOutputs(model) {
+		return nil
+	}
+	if analysisPrompt.MultiPatient {
+		return multiPatientResponseSchema
+	}
+	return singlePatientResponseSchema
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"PennieAI/utils"
+)
+
+func decodeTestResponse(t *testing.T, responseJSON string) AnalysisResponse {
+	t.Helper()
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(responseJSON), &response); err != nil {
+		t.Fatalf("bad test JSON: %v", err)
+	}
+	decoded, err := decodeAnalysisResponse(response)
+	if err != nil {
+		t.Fatalf("decodeAnalysisResponse() error = %v", err)
+	}
+	return decoded
+}
+
+func TestDecodeAnalysisResponseIsLenient(t *testing.T) {
+	decoded := decodeTestResponse(t, `{
+		"patient": {"name": " Bella ", "weight": 12.5, "sex": null, "color": ["black"]},
+		"documents": [
+			{"title": "Exam", "start_line": "3", "end_line": 9},
+			{"title": 2024, "start_line": null, "end_line": 4},
+			"not a document",
+			{"title": "Bad lines", "start_line": 1.5, "end_line": 2}
+		]
+	}`)
+
+	patient := decoded.Patient
+	if patient == nil {
+		t.Fatal("patient was not decoded")
+	}
+	if patient.Name != "Bella" || patient.Weight != "12.5" || patient.Sex != "" || patient.Color != "" {
+		t.Errorf("patient = %+v", *patient)
+	}
+
+	if len(decoded.Documents) != 4 {
+		t.Fatalf("decoded %d documents, want 4", len(decoded.Documents))
+	}
+	if document := decoded.Documents[0]; document.StartLine != 3 || document.EndLine != 9 || document.decodeError != "" {
+		t.Errorf("documents[0] = %+v", document)
+	}
+	if document := decoded.Documents[1]; document.Title != "2024" || document.StartLine != 0 {
+		t.Errorf("documents[1] = %+v", document)
+	}
+	if want := "expected a document object, got string"; decoded.Documents[2].decodeError != want {
+		t.Errorf("documents[2] decodeError = %q, want %q", decoded.Documents[2].decodeError, want)
+	}
+	if decoded.Documents[3].decodeError == "" {
+		t.Error("documents[3] with a fractional start_line decoded without an error")
+	}
+}
+
+func TestDocumentResponseValidate(t *testing.T) {
+	// Lines 11-20 of the file
+	window := utils.Window{StartIndex: 10, WindowLines: make([]string, 10)}
+
+	tests := []struct {
+		name     string
+		document DocumentResponse
+		want     string
+	}{
+		{"valid", DocumentResponse{Title: "Exam", StartLine: 11, EndLine: 20}, ""},
+		{"decode error", DocumentResponse{decodeError: "expected a document object, got string"}, "expected a document object, got string"},
+		{"blank title", DocumentResponse{Title: "  ", StartLine: 11, EndLine: 12}, "missing title"},
+		{"missing end", DocumentResponse{Title: "Exam", StartLine: 11}, "missing start_line or end_line"},
+		{"reversed", DocumentResponse{Title: "Exam", StartLine: 15, EndLine: 12}, "end_line is before start_line"},
+		{"before window", DocumentResponse{Title: "Exam", StartLine: 10, EndLine: 12}, "lines are outside the window (11-20)"},
+		{"after window", DocumentResponse{Title: "Exam", StartLine: 18, EndLine: 21}, "lines are outside the window (11-20)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.document.validate(window); got != tt.want {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
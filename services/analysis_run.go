@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+)
+
+// AnalysisResult is what a finished analysis run hands back to the API
+type AnalysisResult struct {
+	UnprocessedDocumentID int64                     `json:"unprocessedDocumentId"`
+	AnalysisRunID         int64                     `json:"analysisRunId"`
+	Patients              []*models.Patient         `json:"patients"` // Primary patient first
+	Documents             []models.AnalyzedDocument `json:"documents"`
+	Gaps                  []BoundaryGap             `json:"gaps"`
+	ValidationErrors      []DocumentValidationError `json:"validationErrors"` // Documents skipped because the model's output was unusable
+	Coverage              models.CoverageReport     `json:"coverage"`
+	ExtractionErrors      []ExtractionError         `json:"extractionErrors"` // Document extractors that failed, leaving that part of the document empty
+	ResumedWindows        int                       `json:"resumedWindows"`   // Windows restored from a failed attempt's checkpoint
+	Segmenter             string                    `json:"segmenter"`
+}
+
+// AnalysisRequest holds everything needed to analyze and persist an uploaded file
+type AnalysisRequest struct {
+	FileLines []string         `json:"fileLines"`
+	DoctorID  int              `json:"doctorId"`
+	PatientID int              `json:"patientId"` // Optional existing patient to link to, 0 creates a new one
+	Settings  AnalysisSettings `json:"settings"`
+}
+
+/*
+RunAnalysis segments the file with the AI service and stores the whole run in one transaction.
+An existing patient (request.PatientID) takes the place of the primary patient; any other
+patient found in the file is created. Cancelling ctx stops the window queries, or the
+document extractors if the run was already saved.
+*/
+func RunAnalysis(ctx context.Context, request AnalysisRequest, aiService *AIService, opts *AnalyzeOptions) (*AnalysisResult, error) {
+	if opts == nil {
+		opts = &AnalyzeOptions{}
+	}
+	opts.Settings = request.Settings
+	opts.Checkpoint = true
+
+	analysis, err := AnalyzeDocument(ctx, request.FileLines, aiService, opts)
+	if err != nil {
+		return nil, err
+	}
+	coverage := BuildCoverageReport(request.FileLines, analysis.Documents, analysis.Overlaps)
+
+	unprocessedDocument := models.UnprocessedDocument{
+		Content:       strings.Join(request.FileLines, "\n"),
+		NumberOfLines: int64(len(request.FileLines)),
+		Coverage:      &coverage,
+		DoctorID:      &request.DoctorID,
+	}
+
+	preparePatients(analysis.Patients, request.DoctorID)
+	analysis.Patients[0].ID = request.PatientID
+
+	// AnalyzeDocument resolved the defaults, so opts.Settings is what the run actually used
+	run := newAnalysisRun(opts.Settings, analysis.Segmenter, coverage)
+
+	if err := repository.SaveAnalysis(&unprocessedDocument, analysis.Patients, run, analysis.Documents, analysis.FieldSources); err != nil {
+		return nil, err
+	}
+	ClearAnalysisCheckpoint(request.FileLines, opts.Settings)
+
+	extractionErrors := processRunDocuments(ctx, analysis, nil, opts.Settings, aiService, opts.OnDocumentExtracted)
+	attachRunAllergies(analysis.Patients)
+
+	return &AnalysisResult{
+		UnprocessedDocumentID: unprocessedDocument.ID,
+		AnalysisRunID:         run.ID,
+		Patients:              analysis.Patients,
+		Documents:             analysis.Documents,
+		Gaps:                  analysis.Gaps,
+		ValidationErrors:      analysis.ValidationErrors,
+		Coverage:              coverage,
+		ExtractionErrors:      extractionErrors,
+		ResumedWindows:        analysis.ResumedWindows,
+		Segmenter:             analysis.Segmenter,
+	}, nil
+}
+
+// ReanalysisResult is a new run for a stored document, compared against the run that was current before it
+type ReanalysisResult struct {
+	AnalysisResult
+	Promoted   bool          `json:"promoted"`
+	Comparison RunComparison `json:"comparison"`
+}
+
+/*
+ReanalyzeDocument runs segmentation again on a stored upload with different settings.
+The new run is saved alongside the existing ones and linked to the same patient; it only
+replaces the current run if promote is set. Either way it is compared to the previous current run.
+*/
+func ReanalyzeDocument(ctx context.Context, unprocessedDocumentID int64, doctorID int, settings AnalysisSettings, promote bool, aiService *AIService) (*ReanalysisResult, error) {
+	unprocessedDocument, err := repository.FindUnprocessedDocumentForDoctor(unprocessedDocumentID, doctorID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentRun, err := repository.FindCurrentAnalysisRun(unprocessedDocument.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentDocuments, err := repository.GetAnalyzedDocumentsByRunID(currentRun.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentPatients, err := repository.GetPatientsByRunID(currentRun.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileLines := strings.Split(unprocessedDocument.Content, "\n")
+
+	opts := &AnalyzeOptions{Settings: settings, Checkpoint: true}
+	analysis, err := AnalyzeDocument(ctx, fileLines, aiService, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := BuildCoverageReport(fileLines, analysis.Documents, analysis.Overlaps)
+
+	// Patients the current run already created are reused, so runs of one upload share their patients
+	preparePatients(analysis.Patients, doctorID)
+	matchExistingPatients(analysis.Patients, currentPatients, currentRun.PatientID)
+
+	run := newAnalysisRun(opts.Settings, analysis.Segmenter, coverage)
+	run.UnprocessedDocumentID = unprocessedDocument.ID
+
+	if err := repository.SaveAnalysisRun(run, analysis.Patients, analysis.Documents, analysis.FieldSources); err != nil {
+		return nil, err
+	}
+	ClearAnalysisCheckpoint(fileLines, opts.Settings)
+
+	extractionErrors := processRunDocuments(ctx, analysis, currentDocuments, opts.Settings, aiService, nil)
+
+	if promote {
+		if err := repository.PromoteAnalysisRun(unprocessedDocument.ID, run.ID); err != nil {
+			return nil, err
+		}
+		run.IsCurrent = true
+	}
+	attachRunAllergies(analysis.Patients)
+
+	return &ReanalysisResult{
+		AnalysisResult: AnalysisResult{
+			UnprocessedDocumentID: unprocessedDocument.ID,
+			AnalysisRunID:         run.ID,
+			Patients:              analysis.Patients,
+			Documents:             analysis.Documents,
+			Gaps:                  analysis.Gaps,
+			ValidationErrors:      analysis.ValidationErrors,
+			Coverage:              coverage,
+			ExtractionErrors:      extractionErrors,
+			ResumedWindows:        analysis.ResumedWindows,
+			Segmenter:             analysis.Segmenter,
+		},
+		Promoted:   promote,
+		Comparison: CompareRuns(currentRun, currentDocuments, *run, analysis.Documents),
+	}, nil
+}
+
+// processRunDocuments runs the document extractors on a saved run. Results of documents whose
+// lines didn't change are always copied from the previous run, but the AI extractors only run
+// when settings.Extract asks for them, and never for a heuristic segmentation, which promised
+// not to call the AI service; the offline extractors always run.
+func processRunDocuments(ctx context.Context, analysis *DocumentAnalysis, previous []models.AnalyzedDocument, settings AnalysisSettings, aiService *AIService, onExtracted func(DocumentExtraction)) []ExtractionError {
+	if !settings.Extract || analysis.Segmenter != SegmenterAI {
+		aiService = nil
+	}
+	return ProcessDocuments(ctx, aiService, analysis.Documents, previous, settings.Model, settings.Concurrency, onExtracted)
+}
+
+// attachRunAllergies adds the patients' allergies to the result. They are only a convenience
+// there, so a failure is logged rather than failing an analysis that was already saved.
+func attachRunAllergies(patients []*models.Patient) {
+	if err := AttachAllergies(patients); err != nil {
+		log.Printf("⚠️  Failed to load patient allergies: %v", err)
+	}
+}
+
+func newAnalysisRun(settings AnalysisSettings, segmenter string, coverage models.CoverageReport) *models.AnalysisRun {
+	var tokenBudget *int
+	if settings.TokenBudget > 0 {
+		tokenBudget = &settings.TokenBudget
+	}
+
+	return &models.AnalysisRun{
+		Segmenter:     segmenter,
+		BoundaryHints: settings.BoundaryHints,
+		Model:         &settings.Model,
+		PromptVersion: &settings.PromptVersion,
+		WindowSize:    &settings.WindowSize,
+		OverlapSize:   settings.OverlapSize,
+		TokenBudget:   tokenBudget,
+		SnapTolerance: settings.SnapTolerance,
+		Parallel:      settings.Parallel,
+		Coverage:      &coverage,
+	}
+}
+
+func preparePatients(patients []*models.Patient, doctorID int) {
+	for _, patient := range patients {
+		patient.DoctorId = doctorID
+		if patient.Name == "" {
+			// patients.name is NOT NULL, and the model may not find a name in every file
+			patient.Name = models.UnknownPatientName
+		}
+	}
+}
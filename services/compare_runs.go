@@ -0,0 +1,90 @@
+package services
+
+import "PennieAI/models"
+
+// DocumentSpan is the part of an analyzed document a run comparison cares about
+type DocumentSpan struct {
+	Title     string `json:"title"`
+	StartLine int64  `json:"startLine"`
+	EndLine   int64  `json:"endLine"`
+}
+
+// ChangedDocument is a document both runs found, but with different boundaries or title
+type ChangedDocument struct {
+	Base  DocumentSpan `json:"base"`
+	Other DocumentSpan `json:"other"`
+}
+
+// RunComparison shows how two analysis runs of the same upload differ
+type RunComparison struct {
+	BaseRunID      int64             `json:"baseRunId"`
+	OtherRunID     int64             `json:"otherRunId"`
+	Unchanged      []DocumentSpan    `json:"unchanged"`
+	Changed        []ChangedDocument `json:"changed"`
+	OnlyInBase     []DocumentSpan    `json:"onlyInBase"`
+	OnlyInOther    []DocumentSpan    `json:"onlyInOther"`
+	CoverageChange float64           `json:"coverageChange"` // Other minus base, in percentage points
+}
+
+/*
+CompareRuns pairs up the documents of two runs. Documents with identical spans and titles are
+unchanged; ones whose spans nearly match (the same tolerance boundary reconciliation uses)
+are changed; anything left over was only found by one of the runs.
+*/
+func CompareRuns(baseRun models.AnalysisRun, baseDocuments []models.AnalyzedDocument, otherRun models.AnalysisRun, otherDocuments []models.AnalyzedDocument) RunComparison {
+	comparison := RunComparison{
+		BaseRunID:   baseRun.ID,
+		OtherRunID:  otherRun.ID,
+		Unchanged:   []DocumentSpan{},
+		Changed:     []ChangedDocument{},
+		OnlyInBase:  []DocumentSpan{},
+		OnlyInOther: []DocumentSpan{},
+	}
+
+	matched := make([]bool, len(otherDocuments))
+
+	for _, baseDoc := range baseDocuments {
+		base := spanOf(baseDoc)
+		baseCandidate := documentCandidate{StartLine: base.StartLine, EndLine: base.EndLine}
+
+		found := false
+		for i, otherDoc := range otherDocuments {
+			if matched[i] {
+				continue
+			}
+			other := spanOf(otherDoc)
+			if !baseCandidate.nearlyMatches(documentCandidate{StartLine: other.StartLine, EndLine: other.EndLine}) {
+				continue
+			}
+
+			matched[i] = true
+			found = true
+			if base == other {
+				comparison.Unchanged = append(comparison.Unchanged, base)
+			} else {
+				comparison.Changed = append(comparison.Changed, ChangedDocument{Base: base, Other: other})
+			}
+			break
+		}
+
+		if !found {
+			comparison.OnlyInBase = append(comparison.OnlyInBase, base)
+		}
+	}
+
+	for i, otherDoc := range otherDocuments {
+		if !matched[i] {
+			comparison.OnlyInOther = append(comparison.OnlyInOther, spanOf(otherDoc))
+		}
+	}
+
+	if baseRun.Coverage != nil && otherRun.Coverage != nil {
+		comparison.CoverageChange = otherRun.Coverage.CoveragePercent - baseRun.Coverage.CoveragePercent
+	}
+
+	return comparison
+}
+
+func spanOf(doc models.AnalyzedDocument) DocumentSpan {
+	return DocumentSpan{Title: doc.Title, StartLine: doc.StartLine, EndLine: doc.EndLine}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"PennieAI/models"
+)
+
+// documentTypeRule assigns a document type when the title contains any of its keywords as whole words
+type documentTypeRule struct {
+	documentType string
+	keywords     *regexp.Regexp
+}
+
+// titleKeywords matches any of the keywords, or their plural, only where it stands as its own
+// word, so "call" doesn't match "Recall" and "exam" doesn't match "Example"
+func titleKeywords(keywords ...string) *regexp.Regexp {
+	quoted := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		quoted[i] = regexp.QuoteMeta(keyword)
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)s?\b`)
+}
+
+/*
+documentTypeRules are tried in order and the first match wins, so more specific kinds come
+first: "Allergy Treatment Follow-Up Email" is an email, "Post-Surgical Follow-Up & Pathology
+Report" is surgical, and a "Wellness Examination & Vaccination Update" is an examination
+because only a vaccination record/administration title counts as a vaccination record.
+*/
+var documentTypeRules = []documentTypeRule{
+	{models.DocumentTypePhone, titleKeywords("phone", "telephone", "call")},
+	{models.DocumentTypeEmail, titleKeywords("email", "e-mail")},
+	{models.DocumentTypeVaccination, titleKeywords("vaccination record", "vaccination administration", "vaccine certificate", "rabies certificate")},
+	{models.DocumentTypeSurgical, titleKeywords("surgical", "surgery", "excision", "dental prophylaxis", "anesthesia", "procedure")},
+	{models.DocumentTypeImaging, titleKeywords("ultrasound", "radiograph", "x-ray", "imaging", "mri", "ct scan", "echocardiogram", "echocardiography")},
+	{models.DocumentTypeLab, titleKeywords("laboratory", "lab", "test results", "csf", "pathology", "cytology", "histopathology", "urinalysis", "bloodwork", "blood panel")},
+	{models.DocumentTypeConsultation, titleKeywords("consultation", "specialist", "referral")},
+	{models.DocumentTypeExamination, titleKeywords("examination", "exam", "visit", "recheck", "re-evaluation", "check-up", "checkup", "wellness")},
+	{models.DocumentTypeRegistration, titleKeywords("registration", "intake", "patient information")},
+}
+
+// headerDocumentTypes maps the label of a record's dated header line ("Email Date: ...") to the type it suggests
+var headerDocumentTypes = map[string]string{
+	"Email":        models.DocumentTypeEmail,
+	"Procedure":    models.DocumentTypeSurgical,
+	"Surgery":      models.DocumentTypeSurgical,
+	"Consultation": models.DocumentTypeConsultation,
+	"Examination":  models.DocumentTypeExamination,
+	"Visit":        models.DocumentTypeExamination,
+	"Report":       models.DocumentTypeLab,
+}
+
+var phoneModePattern = regexp.MustCompile(`(?i)^Mode:.*\b(phone|telephone|call)\b`)
+
+const (
+	titleTypeConfidence  = 0.7  // The title names the kind of record
+	headerTypeConfidence = 0.5  // Only the dated header line does
+	agreeingSignalBonus  = 0.25 // Title and header (or a "Mode: Telephone" line) agree
+)
+
+/*
+ClassifyDocument assigns one of models.DocumentTypes to a segmented document from its title and
+the first lines of its header. Confidence is 0-1 and fixed per signal, not scaled by which or how
+many keywords matched: a title keyword is the strongest signal, the "<Kind> Date:" header line on
+its own is weaker, and both agreeing is near certain. Documents matching neither are "other" with
+0 confidence.
+*/
+func ClassifyDocument(title string, documentLines []string) (string, float64) {
+	headerType := ""
+	for i := 0; i < len(documentLines) && i <= headerLookahead; i++ {
+		line := strings.TrimSpace(documentLines[i])
+		if match := datedHeaderPattern.FindStringSubmatch(line); match != nil && headerType == "" {
+			// The label's last word is the kind, e.g. "Follow-Up Examination" -> "Examination"
+			words := strings.Fields(match[1])
+			headerType = headerDocumentTypes[words[len(words)-1]]
+		}
+		if phoneModePattern.MatchString(line) {
+			headerType = models.DocumentTypePhone
+		}
+	}
+
+	titleType := ""
+	for _, rule := range documentTypeRules {
+		if rule.keywords.MatchString(title) {
+			titleType = rule.documentType
+			break
+		}
+	}
+
+	switch {
+	case titleType != "" && titleType == headerType:
+		return titleType, titleTypeConfidence + agreeingSignalBonus
+	case titleType != "":
+		return titleType, titleTypeConfidence
+	case headerType != "":
+		return headerType, headerTypeConfidence
+	}
+	return models.DocumentTypeOther, 0
+}
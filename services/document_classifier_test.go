@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"PennieAI/models"
+)
+
+func TestClassifyDocumentTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Telephone Consultation - Vomiting", models.DocumentTypePhone},
+		{"Recall Reminder", models.DocumentTypeOther},
+		{"Example Discharge Notes", models.DocumentTypeOther},
+		{"Wellness Exam", models.DocumentTypeExamination},
+		{"Thoracic Radiographs", models.DocumentTypeImaging},
+		{"MRI Report", models.DocumentTypeImaging},
+		{"Primrie Notes", models.DocumentTypeOther},
+		{"CBC Lab Results", models.DocumentTypeLab},
+		{"Collaboration Notes", models.DocumentTypeOther},
+		{"Allergy Treatment Follow-Up Email", models.DocumentTypeEmail},
+		{"Wellness Examination & Vaccination Update", models.DocumentTypeExamination},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got, confidence := ClassifyDocument(tt.title, nil)
+			if got != tt.want {
+				t.Errorf("ClassifyDocument(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+			if got != models.DocumentTypeOther && confidence != titleTypeConfidence {
+				t.Errorf("ClassifyDocument(%q) confidence = %v, want %v", tt.title, confidence, titleTypeConfidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+)
+
+/*
+documentExtractor is one kind of information read out of every saved document: the summary,
+the medications and so on. extract reads it, with its own AI call unless offline, and stores
+the result. reuse copies what an earlier run extracted from a document with the same
+boundaries, whose text is the same, and reports whether there was anything to copy; offline
+extractors are cheap enough to run again and have none.
+*/
+type documentExtractor struct {
+	name    string
+	offline bool // Doesn't call the AI service, so it also runs for heuristic segmentation
+	extract func(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error
+	reuse   func(document *models.AnalyzedDocument, previous models.AnalyzedDocument) (bool, error)
+}
+
+// documentExtractors run on every document of a run, in this order
+var documentExtractors = []documentExtractor{
+	{name: "summary", extract: SummarizeDocument, reuse: reuseSummary},
+	{name: "medications", extract: ExtractMedications, reuse: reuseRows("medications")},
+	{name: "vaccinations", extract: ExtractVaccinations, reuse: reuseRows("vaccinations")},
+	{name: "lab results", extract: ExtractLabResults, reuse: reuseRows("lab_results")},
+	{name: "problems", extract: ExtractProblems, reuse: reuseRows("problems")},
+	{name: "procedures", extract: ExtractProcedures, reuse: reuseRows("procedures", "procedure_follow_ups")},
+	{name: "allergies", extract: ExtractAllergies, reuse: reuseRows("allergies")},
+	{name: "vitals", offline: true, extract: ExtractVitals},
+}
+
+// DocumentExtraction is what the extractors did with one document
+type DocumentExtraction struct {
+	DocumentID int64             `json:"documentId"`
+	Title      string            `json:"title"`
+	Extracted  []string          `json:"extracted"` // Extractors that ran, including the ones that failed
+	Reused     []string          `json:"reused"`    // Copied from the previous run's document with the same lines
+	Errors     []ExtractionError `json:"errors"`
+}
+
+// ExtractionError is an extractor that failed on a document, which leaves that part of it empty
+type ExtractionError struct {
+	DocumentID int64  `json:"documentId"`
+	Extractor  string `json:"extractor"`
+	Reason     string `json:"reason"`
+}
+
+// reuseRows copies the previous document's rows in tables. A document where nothing was found
+// is extracted again, there is no telling an empty result from a failed one.
+func reuseRows(tables ...string) func(document *models.AnalyzedDocument, previous models.AnalyzedDocument) (bool, error) {
+	return func(document *models.AnalyzedDocument, previous models.AnalyzedDocument) (bool, error) {
+		copied, err := repository.CopyDocumentRows(previous.ID, document, tables...)
+		return copied > 0, err
+	}
+}
+
+/*
+ProcessDocuments runs every document extractor on the documents of a saved run. A document
+whose span is the same as one in previous (the run it replaces) reuses that document's results
+where there are any; everything else costs an AI call. Without an AI service results are still
+reused, but only the offline extractors run.
+
+onExtracted, if set, is called once per document when all of its extractors are done; calls
+never overlap. A failed extractor leaves that part of the document empty and doesn't stop the
+others, the failures are returned. Cancelling ctx stops the extractors that haven't finished.
+*/
+func ProcessDocuments(ctx context.Context, aiService *AIService, documents []models.AnalyzedDocument, previous []models.AnalyzedDocument, model string, concurrency int, onExtracted func(DocumentExtraction)) []ExtractionError {
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(windowConcurrency(concurrency))
+
+	// mu guards the extractions and pending counts, and serializes onExtracted
+	var mu sync.Mutex
+	extractions := make([]DocumentExtraction, len(documents))
+	pending := make([]int, len(documents))
+	var extractionErrors []ExtractionError
+
+	for i := range documents {
+		document := &documents[i]
+		previousDocument, hasPrevious := sameSpanDocument(document, previous)
+
+		extraction := DocumentExtraction{DocumentID: document.ID, Title: document.Title}
+		var toRun []documentExtractor
+		for _, extractor := range documentExtractors {
+			if hasPrevious && extractor.reuse != nil {
+				reused, err := extractor.reuse(document, previousDocument)
+				if err != nil {
+					log.Printf("⚠️  Failed to copy %s to document %d: %v", extractor.name, document.ID, err)
+				}
+				if reused {
+					extraction.Reused = append(extraction.Reused, extractor.name)
+					continue
+				}
+			}
+			if aiService == nil && !extractor.offline {
+				continue
+			}
+			extraction.Extracted = append(extraction.Extracted, extractor.name)
+			toRun = append(toRun, extractor)
+		}
+
+		mu.Lock()
+		extractions[i] = extraction
+		pending[i] = len(toRun)
+		if len(toRun) == 0 && onExtracted != nil {
+			onExtracted(extraction)
+		}
+		mu.Unlock()
+
+		for _, extractor := range toRun {
+			group.Go(func() error {
+				err := extractor.extract(ctx, aiService, document, model)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					log.Printf("⚠️  Failed to extract %s from document %d (%q): %v", extractor.name, document.ID, document.Title, err)
+					extractionError := ExtractionError{DocumentID: document.ID, Extractor: extractor.name, Reason: err.Error()}
+					extractions[i].Errors = append(extractions[i].Errors, extractionError)
+					extractionErrors = append(extractionErrors, extractionError)
+				}
+				pending[i]--
+				if pending[i] == 0 && onExtracted != nil {
+					onExtracted(extractions[i])
+				}
+				// Never fail the group, one document shouldn't cancel the others
+				return nil
+			})
+		}
+	}
+
+	group.Wait()
+	return extractionErrors
+}
+
+func sameSpanDocument(document *models.AnalyzedDocument, previous []models.AnalyzedDocument) (models.AnalyzedDocument, bool) {
+	for _, candidate := range previous {
+		if candidate.StartLine == document.StartLine && candidate.EndLine == document.EndLine {
+			return candidate, true
+		}
+	}
+	return models.AnalyzedDocument{}, false
+}
+
+/*
+queryDocument asks the AI service about one saved document and decodes the answer into
+response. promptTemplate takes the document's title and its lines numbered as in the upload,
+so answers can point at source lines. The inference is linked to the document through
+inferable_type/inferable_id; its ID is returned, nil if it couldn't be saved.
+*/
+func queryDocument(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, promptTemplate string, schema *ResponseSchema, model string, response interface{}) (*int64, error) {
+	var promptLines strings.Builder
+	for i, line := range document.WindowLines {
+		promptLines.WriteString(fmt.Sprintf("%d: %s\n", document.StartLine+int64(i), line))
+	}
+	prompt := fmt.Sprintf(promptTemplate, document.Title, promptLines.String())
+
+	if !SupportsStructuredOutputs(model) {
+		schema = nil
+	}
+
+	var inferenceID *int64
+	parsed, err := aiService.Query(ctx, prompt, &QueryOptions{
+		Model:          model,
+		ResponseSchema: schema,
+		Inferable:      document,
+		Callback: func(inference *models.Inference) {
+			if inference.ID != 0 {
+				id := inference.ID
+				inferenceID = &id
+			}
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("AI query failed: %w", err)
+	}
+
+	responseJSON, _ := json.Marshal(parsed)
+	if err := json.Unmarshal(responseJSON, response); err != nil {
+		return inferenceID, fmt.Errorf("response doesn't match the expected shape: %w", err)
+	}
+
+	return inferenceID, nil
+}
+
+func nonEmptyStrings(values []lenientString) []string {
+	result := []string{}
+	for _, value := range values {
+		if value != "" {
+			result = append(result, string(value))
+		}
+	}
+	return result
+}
@@ -1,16 +1,188 @@
 package services
 
-//
-//import (
-//	"PennieAI/utils"
-//	"strings"
-//)
-//
-//func DocumentSegmenter(file) {
-//	// Use the WindowBuilder from utils to segment the document
-//
-//	fileLines := strings.Split(fileContent, "\n")
-//
-//	builder := utils.NewWindowBuilder()
-//
-//}
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/utils"
+)
+
+// BoundaryCandidate is a line the rule-based segmenter thinks starts a new document
+type BoundaryCandidate struct {
+	StartLine  int64    `json:"startLine"` // 1-based
+	Title      string   `json:"title"`
+	Confidence float64  `json:"confidence"` // 0-1, from how many header signals were found
+	Signals    []string `json:"signals"`
+}
+
+var (
+	// "Examination Date:", "Email Date:", "Follow-Up Date:" ... but not "Date of Birth:"
+	datedHeaderPattern = regexp.MustCompile(`^([A-Z][A-Za-z-]*(?: [A-Z][A-Za-z-]*)*) Date:\s*\S`)
+	// Registration forms open with a patient section instead of a date
+	patientSectionPattern = regexp.MustCompile(`^Patient Information:\s*$`)
+	// Fields that show up in the first few lines of a record header
+	headerFieldPattern = regexp.MustCompile(`^(Patient Name|Owner|From|To|Subject|Mode):`)
+
+	patientFieldPattern = regexp.MustCompile(`^(Patient Name|Name|Species|Breed|Sex|Color/Markings|Color):\s*(.+)$`)
+)
+
+// heuristicPatientFields maps record field labels to the patient field an AI response would put the value in
+var heuristicPatientFields = map[string]func(*PatientResponse) *lenientString{
+	"Patient Name":   func(p *PatientResponse) *lenientString { return &p.Name },
+	"Name":           func(p *PatientResponse) *lenientString { return &p.Name },
+	"Species":        func(p *PatientResponse) *lenientString { return &p.PossibleSpecies },
+	"Breed":          func(p *PatientResponse) *lenientString { return &p.PossibleBreed },
+	"Sex":            func(p *PatientResponse) *lenientString { return &p.Sex },
+	"Color/Markings": func(p *PatientResponse) *lenientString { return &p.Color },
+	"Color":          func(p *PatientResponse) *lenientString { return &p.Color },
+}
+
+const (
+	headerLookahead = 6 // Lines after the anchor searched for supporting header fields
+	titleMaxLength  = 150
+)
+
+/*
+DetectDocumentBoundaries proposes where documents start without calling the AI service.
+
+Each record in our uploads opens with a title line followed by a dated header
+("Examination Date: ...", "Email Date: ...") or a "Patient Information:" section, then a few
+header fields like "Patient Name:" or "From:/To:/Subject:". The dated line (or section line) is
+the anchor; the title above it, a blank line before the title and supporting fields below it
+raise the confidence.
+*/
+func DetectDocumentBoundaries(fileLines []string) []BoundaryCandidate {
+	var candidates []BoundaryCandidate
+
+	for i, line := range fileLines {
+		trimmed := strings.TrimSpace(line)
+
+		var signals []string
+		confidence := 0.0
+
+		if match := datedHeaderPattern.FindStringSubmatch(trimmed); match != nil {
+			signals = append(signals, match[1]+" Date")
+			confidence += 0.5
+		} else if patientSectionPattern.MatchString(trimmed) {
+			signals = append(signals, "Patient Information")
+			confidence += 0.4
+		} else {
+			continue
+		}
+
+		startIndex := i
+		title := ""
+		if i > 0 && isTitleLine(fileLines[i-1]) {
+			startIndex = i - 1
+			title = strings.TrimSpace(fileLines[i-1])
+			signals = append(signals, "title line")
+			confidence += 0.2
+		}
+
+		if startIndex == 0 || strings.TrimSpace(fileLines[startIndex-1]) == "" {
+			signals = append(signals, "preceded by blank line")
+			confidence += 0.1
+		}
+
+		for j := i + 1; j < len(fileLines) && j <= i+headerLookahead; j++ {
+			if field := headerFieldPattern.FindStringSubmatch(strings.TrimSpace(fileLines[j])); field != nil {
+				signals = append(signals, field[1]+" field")
+				confidence += 0.1
+			}
+		}
+
+		if title == "" {
+			title = trimmed
+		}
+
+		candidates = append(candidates, BoundaryCandidate{
+			StartLine:  int64(startIndex + 1),
+			Title:      title,
+			Confidence: math.Round(min(confidence, 1.0)*100) / 100,
+			Signals:    signals,
+		})
+	}
+
+	return candidates
+}
+
+// isTitleLine is a non-blank line that reads like a heading rather than a "Field: value" line
+func isTitleLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && !strings.Contains(trimmed, ":") && len(trimmed) <= titleMaxLength
+}
+
+/*
+SegmentDocumentHeuristically turns the detected boundaries into documents: each one runs from
+its start line to the last non-blank line before the next boundary. It also fills in whatever
+patient fields it can read from "Field: value" lines. Used when no AI call should be made.
+*/
+func SegmentDocumentHeuristically(fileLines []string) *DocumentAnalysis {
+	boundaries := DetectDocumentBoundaries(fileLines)
+
+	candidates := make([]documentCandidate, 0, len(boundaries))
+	for i, boundary := range boundaries {
+		endLine := int64(len(fileLines))
+		if i+1 < len(boundaries) {
+			endLine = boundaries[i+1].StartLine - 1
+		}
+		for endLine > boundary.StartLine && strings.TrimSpace(fileLines[endLine-1]) == "" {
+			endLine--
+		}
+
+		candidates = append(candidates, documentCandidate{
+			Title:     boundary.Title,
+			StartLine: boundary.StartLine,
+			EndLine:   endLine,
+		})
+	}
+
+	documents, gaps, overlaps := reconcileBoundaries(candidates, fileLines)
+	patients := extractPatientsHeuristically(documents)
+
+	return &DocumentAnalysis{
+		Patients:     finalizePatients(patients.patients, documents, patients.sources),
+		FieldSources: patients.sources,
+		Documents:    documents,
+		Gaps:         gaps,
+		Overlaps:     overlaps,
+		Segmenter:    SegmenterHeuristic,
+	}
+}
+
+/*
+extractPatientsHeuristically reads the "Field: value" lines of each document and assigns the
+document to the patient it names. The first value of each field in a document wins, since
+"Name:" is also used further down for owners and emergency contacts.
+*/
+func extractPatientsHeuristically(documents []models.AnalyzedDocument) patientSet {
+	var patients patientSet
+
+	for i := range documents {
+		var patientData PatientResponse
+		found := false
+
+		for _, line := range documents[i].WindowLines {
+			match := patientFieldPattern.FindStringSubmatch(strings.TrimSpace(line))
+			if match == nil {
+				continue
+			}
+
+			field := heuristicPatientFields[match[1]](&patientData)
+			if *field == "" {
+				*field = lenientString(strings.TrimSpace(match[2]))
+				found = true
+			}
+		}
+
+		documents[i].PatientIndex = -1
+		if found {
+			origin := fieldOrigin{Window: utils.Window{StartIndex: int(documents[i].StartLine - 1), WindowLines: documents[i].WindowLines}}
+			documents[i].PatientIndex = patients.merge(patientData, origin)
+		}
+	}
+
+	return patients
+}
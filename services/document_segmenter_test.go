@@ -0,0 +1,38 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func readMockLines(t *testing.T, name string) []string {
+	t.Helper()
+	content, err := os.ReadFile("../mock_data/" + name)
+	if err != nil {
+		t.Fatalf("failed to read mock file: %v", err)
+	}
+	// The single-record files have Windows line endings, combined.txt doesn't
+	return strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+}
+
+// combined.txt is pennie01.txt to pennie32.txt joined, so each of them should come back as one document
+func TestSegmentDocumentHeuristicallyFindsEveryMockDocument(t *testing.T) {
+	analysis := SegmentDocumentHeuristically(readMockLines(t, "combined.txt"))
+
+	if len(analysis.Documents) != 32 {
+		t.Fatalf("found %d documents, want 32", len(analysis.Documents))
+	}
+	if len(analysis.Gaps) != 0 {
+		t.Errorf("found gaps %+v, want none", analysis.Gaps)
+	}
+
+	for i, document := range analysis.Documents {
+		name := fmt.Sprintf("pennie%02d.txt", i+1)
+		want := strings.TrimSpace(strings.Join(readMockLines(t, name), "\n"))
+		if got := strings.TrimSpace(document.Content); got != want {
+			t.Errorf("document %d (%q, lines %d-%d) doesn't match %s", i, document.Title, document.StartLine, document.EndLine, name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// documentSummaryResponse is the shape prompts.DocumentSummaryPrompt asks for
+type documentSummaryResponse struct {
+	ReasonForVisit lenientString   `json:"reason_for_visit" description:"why the patient was seen, or why the owner got in touch"`
+	Findings       []lenientString `json:"findings" description:"key exam, test or imaging results and diagnoses"`
+	Plan           []lenientString `json:"plan" description:"treatment, medications, follow-up and owner instructions"`
+}
+
+var documentSummarySchema = &ResponseSchema{
+	Name:   "document_summary",
+	Schema: utils.JSONSchemaFor(documentSummaryResponse{}),
+}
+
+// SummarizeDocument asks the AI service for a short summary of a saved document and stores it
+// with the span it was written for
+func SummarizeDocument(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	var decoded documentSummaryResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.DocumentSummaryPrompt, documentSummarySchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	summary := &models.DocumentSummary{
+		ReasonForVisit: string(decoded.ReasonForVisit),
+		Findings:       nonEmptyStrings(decoded.Findings),
+		Plan:           nonEmptyStrings(decoded.Plan),
+		InferenceID:    inferenceID,
+	}
+	return setDocumentSummary(document, summary)
+}
+
+// reuseSummary keeps the previous document's summary if it was written for the same lines
+func reuseSummary(document *models.AnalyzedDocument, previous models.AnalyzedDocument) (bool, error) {
+	if !previous.SummaryIsCurrent() {
+		return false, nil
+	}
+	return true, setDocumentSummary(document, previous.Summary)
+}
+
+func setDocumentSummary(document *models.AnalyzedDocument, summary *models.DocumentSummary) error {
+	startLine := document.StartLine
+	endLine := document.EndLine
+	document.Summary = summary
+	document.SummaryStartLine = &startLine
+	document.SummaryEndLine = &endLine
+
+	return repository.UpdateAnalyzedDocumentSummary(document)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// labResultsResponse is the shape prompts.LabResultExtractionPrompt asks for
+type labResultsResponse struct {
+	LabResults []labResultResponse `json:"lab_results"`
+}
+
+type labResultResponse struct {
+	Panel          lenientString `json:"panel"`
+	Analyte        lenientString `json:"analyte"`
+	Value          lenientString `json:"value" description:"the result as written, e.g. 12.1, Within normal range, Negative"`
+	Unit           lenientString `json:"unit"`
+	ReferenceRange lenientString `json:"reference_range" description:"as written, e.g. 5.5-16.9, empty if not stated"`
+	Flag           lenientString `json:"flag" enum:"normal,high,low,abnormal,unknown"`
+	CollectedDate  lenientString `json:"collected_date" description:"yyyy-MM-dd if the record gives a collection date, otherwise empty"`
+	StartLine      lenientInt    `json:"start_line"`
+	EndLine        lenientInt    `json:"end_line"`
+}
+
+var labResultsSchema = &ResponseSchema{
+	Name:   "lab_results",
+	Schema: utils.JSONSchemaFor(labResultsResponse{}),
+}
+
+var labFlags = map[string]bool{
+	models.LabFlagNormal:   true,
+	models.LabFlagHigh:     true,
+	models.LabFlagLow:      true,
+	models.LabFlagAbnormal: true,
+	models.LabFlagUnknown:  true,
+}
+
+var (
+	// A value that starts with a number: "12.1", "< 0.5"
+	labValuePattern = regexp.MustCompile(`^[<>≤≥]?\s*(-?\d+(?:\.\d+)?)`)
+	// "5.5-16.9", "5.5 – 16.9", "5.5 to 16.9"
+	labRangePattern = regexp.MustCompile(`(-?\d+(?:\.\d+)?)\s*(?:-|–|—|to)\s*(-?\d+(?:\.\d+)?)`)
+	// "< 5", "> 2.0"
+	labBoundPattern = regexp.MustCompile(`^([<>≤≥])\s*(-?\d+(?:\.\d+)?)`)
+)
+
+// ExtractLabResults asks the AI service which lab results a saved document reports and stores
+// them for the document's patient, replacing what an earlier extraction found. Results are
+// dated by the document's service date unless the record gives a collection date.
+func ExtractLabResults(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	var decoded labResultsResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.LabResultExtractionPrompt, labResultsSchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var results []models.LabResult
+	for _, item := range decoded.LabResults {
+		analyte := strings.TrimSpace(string(item.Analyte))
+		valueText := strings.TrimSpace(string(item.Value))
+		if analyte == "" || valueText == "" {
+			continue
+		}
+
+		result := models.LabResult{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			Panel:              optionalString(item.Panel),
+			Analyte:            analyte,
+			ValueText:          valueText,
+			Unit:               optionalString(item.Unit),
+			ReferenceRange:     optionalString(item.ReferenceRange),
+			CollectedOn:        parseExtractedDate(item.CollectedDate),
+			InferenceID:        inferenceID,
+		}
+		if result.CollectedOn == nil {
+			result.CollectedOn = document.ServiceDate
+		}
+		if match := labValuePattern.FindStringSubmatch(valueText); match != nil {
+			if value, err := strconv.ParseFloat(match[1], 64); err == nil {
+				result.Value = &value
+			}
+		}
+		result.ReferenceLow, result.ReferenceHigh = parseReferenceRange(string(item.ReferenceRange))
+		result.Flag = labFlag(result, strings.ToLower(string(item.Flag)))
+		result.StartLine, result.EndLine, result.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		results = append(results, result)
+	}
+
+	return repository.ReplaceDocumentLabResults(document.ID, results)
+}
+
+// parseReferenceRange reads "low-high" or a one-sided "<high" / ">low" range
+func parseReferenceRange(text string) (*float64, *float64) {
+	text = strings.TrimSpace(text)
+
+	if match := labRangePattern.FindStringSubmatch(text); match != nil {
+		low, lowErr := strconv.ParseFloat(match[1], 64)
+		high, highErr := strconv.ParseFloat(match[2], 64)
+		if lowErr == nil && highErr == nil && low <= high {
+			return &low, &high
+		}
+	}
+	if match := labBoundPattern.FindStringSubmatch(text); match != nil {
+		bound, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return nil, nil
+		}
+		if match[1] == "<" || match[1] == "≤" {
+			return nil, &bound
+		}
+		return &bound, nil
+	}
+	return nil, nil
+}
+
+// labFlag compares a numeric value with its reference range. Without both, it goes by what the
+// record said about the value, as the model reported it.
+func labFlag(result models.LabResult, reportedFlag string) string {
+	if result.Value != nil && (result.ReferenceLow != nil || result.ReferenceHigh != nil) {
+		switch {
+		case result.ReferenceHigh != nil && *result.Value > *result.ReferenceHigh:
+			return models.LabFlagHigh
+		case result.ReferenceLow != nil && *result.Value < *result.ReferenceLow:
+			return models.LabFlagLow
+		}
+		return models.LabFlagNormal
+	}
+
+	if labFlags[reportedFlag] {
+		return reportedFlag
+	}
+	return models.LabFlagUnknown
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+
+	"PennieAI/models"
+)
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
+
+func TestParseReferenceRange(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantLow  *float64
+		wantHigh *float64
+	}{
+		{"5.5-16.9", floatPointer(5.5), floatPointer(16.9)},
+		{"5.5 – 16.9", floatPointer(5.5), floatPointer(16.9)},
+		{"0.5 to 1.8 mg/dL", floatPointer(0.5), floatPointer(1.8)},
+		{"< 5", nil, floatPointer(5)},
+		{"≥2.0", floatPointer(2), nil},
+		{"16.9-5.5", nil, nil},
+		{"Negative", nil, nil},
+		{"", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			low, high := parseReferenceRange(tt.text)
+			if !equalFloatPointers(low, tt.wantLow) || !equalFloatPointers(high, tt.wantHigh) {
+				t.Errorf("parseReferenceRange(%q) = %v, %v; want %v, %v", tt.text, formatFloatPointer(low), formatFloatPointer(high), formatFloatPointer(tt.wantLow), formatFloatPointer(tt.wantHigh))
+			}
+		})
+	}
+}
+
+func TestLabFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   models.LabResult
+		reported string
+		want     string
+	}{
+		{"above range", models.LabResult{Value: floatPointer(18), ReferenceLow: floatPointer(5.5), ReferenceHigh: floatPointer(16.9)}, "normal", models.LabFlagHigh},
+		{"below range", models.LabResult{Value: floatPointer(4), ReferenceLow: floatPointer(5.5), ReferenceHigh: floatPointer(16.9)}, "", models.LabFlagLow},
+		{"in range overrides reported flag", models.LabResult{Value: floatPointer(12.1), ReferenceLow: floatPointer(5.5), ReferenceHigh: floatPointer(16.9)}, "high", models.LabFlagNormal},
+		{"on the bound", models.LabResult{Value: floatPointer(16.9), ReferenceLow: floatPointer(5.5), ReferenceHigh: floatPointer(16.9)}, "", models.LabFlagNormal},
+		{"upper bound only", models.LabResult{Value: floatPointer(6), ReferenceHigh: floatPointer(5)}, "", models.LabFlagHigh},
+		{"lower bound only", models.LabResult{Value: floatPointer(1), ReferenceLow: floatPointer(2)}, "", models.LabFlagLow},
+		{"no range uses reported flag", models.LabResult{Value: floatPointer(12.1)}, "high", models.LabFlagHigh},
+		{"text value", models.LabResult{}, "abnormal", models.LabFlagAbnormal},
+		{"unrecognised reported flag", models.LabResult{}, "critical", models.LabFlagUnknown},
+		{"nothing reported", models.LabResult{}, "", models.LabFlagUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labFlag(tt.result, tt.reported); got != tt.want {
+				t.Errorf("labFlag() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalFloatPointers(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatFloatPointer(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
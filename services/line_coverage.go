@@ -0,0 +1,81 @@
+package services
+
+import (
+	"math"
+	"strings"
+
+	"PennieAI/models"
+)
+
+/*
+BuildCoverageReport checks every line of the upload against the analyzed documents.
+
+Blank lines are expected between documents, so they are left out of the percentage and
+unassigned ranges are trimmed down to the lines that actually have text on them. Documents
+never overlap once their boundaries are reconciled, so a line is either assigned or not; the
+overlapping assignments reconciliation resolved (see reconcileBoundaries) are reported as given.
+*/
+func BuildCoverageReport(fileLines []string, documents []models.AnalyzedDocument, overlaps []models.LineOverlap) models.CoverageReport {
+	totalLines := int64(len(fileLines))
+
+	// assigned[i] is set when a document includes line i+1
+	assigned := make([]bool, totalLines)
+	for _, doc := range documents {
+		start := max(doc.StartLine, 1)
+		end := min(doc.EndLine, totalLines)
+		for line := start; line <= end; line++ {
+			assigned[line-1] = true
+		}
+	}
+
+	report := models.CoverageReport{
+		TotalLines:       totalLines,
+		UnassignedRanges: []models.LineRange{},
+		Overlaps:         overlaps,
+	}
+	if report.Overlaps == nil {
+		report.Overlaps = []models.LineOverlap{}
+	}
+
+	var unassignedStart int64 = 0 // 0 while we're not inside an unassigned range
+	var lastContentLine int64 = 0 // Last non-blank line of the current unassigned range
+
+	for i, line := range fileLines {
+		lineNumber := int64(i + 1)
+		isBlank := strings.TrimSpace(line) == ""
+
+		if !isBlank {
+			report.ContentLines++
+		}
+
+		if assigned[i] {
+			if !isBlank {
+				report.AssignedLines++
+			}
+			if unassignedStart != 0 {
+				report.UnassignedRanges = append(report.UnassignedRanges, models.LineRange{StartLine: unassignedStart, EndLine: lastContentLine})
+				unassignedStart = 0
+			}
+			continue
+		}
+
+		if isBlank {
+			continue
+		}
+		if unassignedStart == 0 {
+			unassignedStart = lineNumber
+		}
+		lastContentLine = lineNumber
+	}
+
+	if unassignedStart != 0 {
+		report.UnassignedRanges = append(report.UnassignedRanges, models.LineRange{StartLine: unassignedStart, EndLine: lastContentLine})
+	}
+
+	if report.ContentLines > 0 {
+		percent := float64(report.AssignedLines) / float64(report.ContentLines) * 100
+		report.CoveragePercent = math.Round(percent*100) / 100
+	}
+
+	return report
+}
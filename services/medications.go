@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// medicationsResponse is the shape prompts.MedicationExtractionPrompt asks for
+type medicationsResponse struct {
+	Medications []medicationResponse `json:"medications"`
+}
+
+type medicationResponse struct {
+	Name         lenientString `json:"name"`
+	Dose         lenientString `json:"dose" description:"the amount as a number only, e.g. 0.5; empty if not stated"`
+	Unit         lenientString `json:"unit" description:"unit of the dose, e.g. mg, mg/kg, ml"`
+	Route        lenientString `json:"route"`
+	Frequency    lenientString `json:"frequency"`
+	StartDate    lenientString `json:"start_date" description:"yyyy-MM-dd, empty if not stated"`
+	StopDate     lenientString `json:"stop_date" description:"yyyy-MM-dd, empty if not stated"`
+	Status       lenientString `json:"status" enum:"active,completed,discontinued,unknown"`
+	PrescribedBy lenientString `json:"prescribed_by"`
+	StartLine    lenientInt    `json:"start_line" description:"first line that mentions the medication"`
+	EndLine      lenientInt    `json:"end_line" description:"last line that mentions the medication"`
+}
+
+var medicationsSchema = &ResponseSchema{
+	Name:   "medications",
+	Schema: utils.JSONSchemaFor(medicationsResponse{}),
+}
+
+var medicationStatuses = map[string]bool{
+	models.MedicationStatusActive:       true,
+	models.MedicationStatusCompleted:    true,
+	models.MedicationStatusDiscontinued: true,
+	models.MedicationStatusUnknown:      true,
+}
+
+// ExtractMedications asks the AI service which medications a saved document mentions and stores
+// them for the document's patient, replacing what an earlier extraction found
+func ExtractMedications(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	var decoded medicationsResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.MedicationExtractionPrompt, medicationsSchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var medications []models.Medication
+	for _, item := range decoded.Medications {
+		name := strings.TrimSpace(string(item.Name))
+		if name == "" {
+			continue
+		}
+
+		status := strings.ToLower(string(item.Status))
+		if !medicationStatuses[status] {
+			status = models.MedicationStatusUnknown
+		}
+
+		medication := models.Medication{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			Name:               name,
+			Unit:               optionalString(item.Unit),
+			Route:              optionalString(item.Route),
+			Frequency:          optionalString(item.Frequency),
+			StartDate:          parseExtractedDate(item.StartDate),
+			StopDate:           parseExtractedDate(item.StopDate),
+			Status:             status,
+			PrescribedBy:       optionalString(item.PrescribedBy),
+			InferenceID:        inferenceID,
+		}
+		if dose, err := strconv.ParseFloat(strings.TrimSpace(string(item.Dose)), 64); err == nil {
+			medication.Dose = &dose
+		}
+		medication.StartLine, medication.EndLine, medication.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		medications = append(medications, medication)
+	}
+
+	return repository.ReplaceDocumentMedications(document.ID, medications)
+}
+
+func optionalString(value lenientString) *string {
+	trimmed := strings.TrimSpace(string(value))
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// parseExtractedDate reads a date the model was asked to give as yyyy-MM-dd, accepting the
+// formats our records use as well. Returns nil if there is no date.
+func parseExtractedDate(value lenientString) *time.Time {
+	text := strings.TrimSpace(string(value))
+	if date, err := time.Parse("2006-01-02", text); err == nil {
+		return &date
+	}
+	if date, ok := parseServiceDate(text); ok {
+		return &date
+	}
+	return nil
+}
+
+// documentSourceLines checks a line span the model gave against the document and returns it
+// with its text, or nils if it isn't inside the document
+func documentSourceLines(document *models.AnalyzedDocument, startLine int64, endLine int64) (*int64, *int64, *string) {
+	if endLine == 0 {
+		endLine = startLine
+	}
+	if startLine < document.StartLine || endLine > document.EndLine || endLine < startLine {
+		return nil, nil, nil
+	}
+
+	lines := document.WindowLines[startLine-document.StartLine : endLine-document.StartLine+1]
+	text := strings.Join(lines, "\n")
+	return &startLine, &endLine, &text
+}
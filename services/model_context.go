@@ -0,0 +1,64 @@
+package services
+
+import "strings"
+
+// Context windows in tokens, matched by model name prefix. The longest matching prefix wins,
+// so "gpt-4o" isn't mistaken for "gpt-4".
+var modelContextWindows = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"gpt-5":         400000,
+	"o1":            200000,
+	"o3":            200000,
+	"o4-mini":       200000,
+}
+
+const (
+	// Unknown models get the smallest window we've used, better a few extra windows than a rejected prompt
+	defaultModelContextWindow = 8192
+	// Room left in the context for the JSON the model writes back
+	responseTokenReserve = 4096
+)
+
+// ModelPromptLimit is the most prompt tokens the model accepts while still leaving room for its response
+func ModelPromptLimit(model string) int {
+	contextWindow := defaultModelContextWindow
+	longestPrefix := 0
+
+	for prefix, tokens := range modelContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longestPrefix {
+			contextWindow = tokens
+			longestPrefix = len(prefix)
+		}
+	}
+
+	return contextWindow - responseTokenReserve
+}
+
+// Model name prefixes that accept a JSON Schema response_format. Older models only take json_object.
+var structuredOutputModels = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4-mini"}
+
+// Models under those prefixes that reject a JSON Schema response_format: the first gpt-4o snapshot
+// predates structured outputs, and the o1 previews don't take response_format at all
+var unstructuredOutputModels = []string{"gpt-4o-2024-05-13", "o1-mini", "o1-preview"}
+
+// SupportsStructuredOutputs reports whether responses from model can be constrained to a JSON Schema
+func SupportsStructuredOutputs(model string) bool {
+	if model == "" {
+		model = GetModelVersion()
+	}
+	for _, prefix := range unstructuredOutputModels {
+		if strings.HasPrefix(model, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range structuredOutputModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package services
+
+import "testing"
+
+func TestSupportsStructuredOutputs(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"gpt-4o", true},
+		{"gpt-4o-2024-08-06", true},
+		{"gpt-4o-2024-05-13", false},
+		{"gpt-4o-mini", true},
+		{"gpt-4.1-mini", true},
+		{"o1", true},
+		{"o1-2024-12-17", true},
+		{"o1-mini", false},
+		{"o1-mini-2024-09-12", false},
+		{"o1-preview", false},
+		{"o3-mini", true},
+		{"gpt-4-turbo", false},
+		{"gpt-3.5-turbo", false},
+	}
+
+	for _, tt := range tests {
+		if got := SupportsStructuredOutputs(tt.model); got != tt.want {
+			t.Errorf("SupportsStructuredOutputs(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
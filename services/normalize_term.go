@@ -0,0 +1,26 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+var nonWordPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeTerm lowercases a clinical term (a diagnosis, allergen, drug or procedure name) and
+// reduces punctuation and underscores to single spaces
+func normalizeTerm(term string) string {
+	return strings.TrimSpace(nonWordPattern.ReplaceAllString(strings.ToLower(term), " "))
+}
+
+// stemTerm normalizes a term and drops the plural "s" of each word, so "Cephalosporins" and
+// "cephalosporin" compare equal. Stemmed terms should only be compared with other stemmed terms.
+func stemTerm(term string) string {
+	words := strings.Fields(normalizeTerm(term))
+	for i, word := range words {
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			words[i] = strings.TrimSuffix(word, "s")
+		}
+	}
+	return strings.Join(words, " ")
+}
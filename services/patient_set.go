@@ -0,0 +1,308 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/utils"
+)
+
+/*
+patientSet collects the patients an analysis finds across windows. Windows report patients by
+name, so a name seen before merges into the existing patient instead of creating another one.
+A window patient without a name can only be placed when there is at most one patient so far;
+otherwise its fields are dropped rather than blended into the wrong animal.
+
+Every value that sets or changes a field is kept as a source, see fieldOrigin.
+*/
+type patientSet struct {
+	patients []*models.Patient
+	sources  []models.PatientFieldSource
+}
+
+// fieldOrigin is where a batch of patient fields was read: a window of the upload and the inference that read it
+type fieldOrigin struct {
+	Window      utils.Window
+	WindowIndex *int
+	InferenceID *int64
+}
+
+// fieldLabels are the labels, lowercase, records write each patient field under
+var fieldLabels = map[string][]string{
+	"name":            {"name", "patient name", "patient", "pet name"},
+	"possibleSpecies": {"species"},
+	"possibleBreed":   {"breed"},
+	"sex":             {"sex", "gender", "sex/gender"},
+	"color":           {"color", "colour", "color/markings", "markings"},
+	"dateOfBirth":     {"date of birth", "dob", "birth date", "birthdate"},
+	"weight":          {"weight", "body weight", "current weight"},
+	"height":          {"height"},
+}
+
+// labeledLinePattern splits a "Label: value" line
+var labeledLinePattern = regexp.MustCompile(`^([^:]{1,40}):(.*)$`)
+
+/*
+source locates value in the origin's lines: the first "Label: value" line with one of the
+field's labels whose value contains it as whole words, ignoring case. A value found anywhere
+else can't be told apart from a coincidence ("M" in "Female", "Lab" in "Laboratory"), so when
+the model reworded the value or the record doesn't label it, the line is left empty.
+*/
+func (o fieldOrigin) source(patientIndex int, field string, value string) models.PatientFieldSource {
+	source := models.PatientFieldSource{
+		Field:        field,
+		Value:        value,
+		WindowIndex:  o.WindowIndex,
+		InferenceID:  o.InferenceID,
+		PatientIndex: patientIndex,
+	}
+
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return source
+	}
+	valuePattern := regexp.MustCompile(`(?i)(?:^|[^\pL\pN])` + regexp.QuoteMeta(trimmed) + `(?:$|[^\pL\pN])`)
+
+	for i, line := range o.Window.WindowLines {
+		match := labeledLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || !isFieldLabel(field, match[1]) || !valuePattern.MatchString(match[2]) {
+			continue
+		}
+		lineNumber := int64(o.Window.StartIndex + i + 1)
+		lineText := line
+		source.LineNumber = &lineNumber
+		source.LineText = &lineText
+		break
+	}
+
+	return source
+}
+
+// isFieldLabel reports whether label, as written before a colon, is one of field's labels
+func isFieldLabel(field string, label string) bool {
+	label = strings.ToLower(strings.Trim(label, " \t-*•"))
+	for _, candidate := range fieldLabels[field] {
+		if label == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePatientName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// indexOf returns the position of the patient with this name, or -1
+func (s *patientSet) indexOf(name string) int {
+	normalized := normalizePatientName(name)
+	if normalized == "" {
+		return -1
+	}
+	for i, patient := range s.patients {
+		if normalizePatientName(patient.Name) == normalized {
+			return i
+		}
+	}
+	return -1
+}
+
+// merge folds one window's patient into the set and returns its index, or -1 if it couldn't be placed
+func (s *patientSet) merge(patientData PatientResponse, origin fieldOrigin) int {
+	name := strings.TrimSpace(string(patientData.Name))
+
+	index := s.indexOf(name)
+	if index == -1 {
+		switch {
+		case name == "" && len(s.patients) > 1:
+			return -1
+		case len(s.patients) == 1 && (name == "" || s.patients[0].Name == ""):
+			// Either side may be the first window that saw the name
+			index = 0
+		default:
+			s.patients = append(s.patients, &models.Patient{})
+			index = len(s.patients) - 1
+		}
+	}
+
+	for _, change := range mergePatientFields(s.patients[index], patientData) {
+		s.sources = append(s.sources, origin.source(index, change.field, change.value))
+	}
+	return index
+}
+
+// fieldChange is a patient field a window set or changed
+type fieldChange struct {
+	field string
+	value string
+}
+
+// mergePatientFields copies the non-empty fields of a window's patient, collecting every species and breed seen.
+// Dates and measurements the model gave in a form that can't be read are ignored.
+// It returns the fields whose value changed, with the value as the model gave it.
+func mergePatientFields(patient *models.Patient, patientData PatientResponse) []fieldChange {
+	var changes []fieldChange
+
+	// A name that only differs in case is the same name, not a new value with its own source
+	if name := string(patientData.Name); name != "" && normalizePatientName(name) != normalizePatientName(patient.Name) {
+		patient.Name = name
+		changes = append(changes, fieldChange{"name", name})
+	}
+	if species := string(patientData.PossibleSpecies); species != "" {
+		var added bool
+		if patient.PossibleSpecies, added = appendUnique(patient.PossibleSpecies, species); added {
+			changes = append(changes, fieldChange{"possibleSpecies", species})
+		}
+	}
+	if breed := string(patientData.PossibleBreed); breed != "" {
+		var added bool
+		if patient.PossibleBreed, added = appendUnique(patient.PossibleBreed, breed); added {
+			changes = append(changes, fieldChange{"possibleBreed", breed})
+		}
+	}
+	if sex := string(patientData.Sex); sex != "" && (patient.Sex == nil || *patient.Sex != sex) {
+		patient.Sex = &sex
+		changes = append(changes, fieldChange{"sex", sex})
+	}
+	if color := string(patientData.Color); color != "" && (patient.Color == nil || *patient.Color != color) {
+		patient.Color = &color
+		changes = append(changes, fieldChange{"color", color})
+	}
+	if dateOfBirth := parseExtractedDate(patientData.DateOfBirth); dateOfBirth != nil && (patient.DateOfBirth == nil || !patient.DateOfBirth.Equal(*dateOfBirth)) {
+		patient.DateOfBirth = dateOfBirth
+		changes = append(changes, fieldChange{"dateOfBirth", strings.TrimSpace(string(patientData.DateOfBirth))})
+	}
+	if weight, ok := parseMeasurement(string(patientData.Weight), weightToKg); ok && (patient.Weight == nil || *patient.Weight != weight) {
+		patient.Weight = &weight
+		changes = append(changes, fieldChange{"weight", strings.TrimSpace(string(patientData.Weight))})
+	}
+	if height, ok := parseMeasurement(string(patientData.Height), heightToCm); ok && (patient.Height == nil || *patient.Height != height) {
+		patient.Height = &height
+		changes = append(changes, fieldChange{"height", strings.TrimSpace(string(patientData.Height))})
+	}
+
+	return changes
+}
+
+// measurementPattern reads a number and its unit, e.g. "65 lbs", "22.5kg", "20 in"
+var measurementPattern = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([a-zA-Z"']*)`)
+
+// parseMeasurement reads a measurement and converts it with convert, which also rejects
+// unknown units. A number without a unit could be anything, so it isn't used.
+func parseMeasurement(text string, convert func(value float64, unit string) (float64, bool)) (float64, bool) {
+	match := measurementPattern.FindStringSubmatch(text)
+	if match == nil || match[2] == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return convert(value, match[2])
+}
+
+func weightToKg(value float64, unit string) (float64, bool) {
+	return normalizeVital(models.VitalWeight, value, unit)
+}
+
+func heightToCm(value float64, unit string) (float64, bool) {
+	switch strings.ToLower(unit) {
+	case "cm", "centimeter", "centimeters":
+		return value, true
+	case "m", "meter", "meters":
+		return value * 100, true
+	case "in", "inch", "inches", "\"":
+		return value * 2.54, true
+	case "ft", "foot", "feet", "'":
+		return value * 30.48, true
+	}
+	return 0, false
+}
+
+func appendUnique(values *[]string, value string) (*[]string, bool) {
+	if values == nil {
+		return &[]string{value}, true
+	}
+	for _, existing := range *values {
+		if existing == value {
+			return values, false
+		}
+	}
+	*values = append(*values, value)
+	return values, true
+}
+
+/*
+finalizePatients makes sure every document belongs to a patient and orders the patients by how
+many documents they have, so patients[0] is the upload's primary patient (the one an existing
+patient_id links to and the one recorded on the analysis run). Document and field source
+indexes are remapped.
+*/
+func finalizePatients(patients []*models.Patient, documents []models.AnalyzedDocument, sources []models.PatientFieldSource) []*models.Patient {
+	if len(patients) == 0 {
+		patients = []*models.Patient{{}}
+	}
+
+	counts := make([]int, len(patients))
+	for i := range documents {
+		if documents[i].PatientIndex < 0 || documents[i].PatientIndex >= len(patients) {
+			// The model didn't say who the document is about, it most likely belongs to the main patient
+			documents[i].PatientIndex = 0
+		}
+		counts[documents[i].PatientIndex]++
+	}
+
+	order := make([]int, len(patients))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+
+	ordered := make([]*models.Patient, len(patients))
+	newIndex := make([]int, len(patients))
+	for position, oldIndex := range order {
+		ordered[position] = patients[oldIndex]
+		newIndex[oldIndex] = position
+	}
+	for i := range documents {
+		documents[i].PatientIndex = newIndex[documents[i].PatientIndex]
+	}
+	for i := range sources {
+		sources[i].PatientIndex = newIndex[sources[i].PatientIndex]
+	}
+
+	return ordered
+}
+
+/*
+matchExistingPatients gives patients found by a re-analysis the IDs of the patients an earlier
+run created, matching by name. If both runs found exactly one patient they are the same one,
+even if the name was read differently; runs saved before documents were linked to patients
+only have legacyPatientID.
+*/
+func matchExistingPatients(patients []*models.Patient, existing []*models.Patient, legacyPatientID *int64) {
+	if len(existing) == 0 && legacyPatientID != nil {
+		existing = []*models.Patient{{ID: int(*legacyPatientID)}}
+	}
+
+	if len(patients) == 1 && len(existing) == 1 {
+		patients[0].ID = existing[0].ID
+		return
+	}
+
+	used := make([]bool, len(existing))
+	for _, patient := range patients {
+		for i, candidate := range existing {
+			if !used[i] && normalizePatientName(candidate.Name) == normalizePatientName(patient.Name) {
+				patient.ID = candidate.ID
+				used[i] = true
+				break
+			}
+		}
+	}
+}
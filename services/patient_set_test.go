@@ -0,0 +1,160 @@
+package services
+
+import (
+	"testing"
+
+	"PennieAI/models"
+	"PennieAI/utils"
+)
+
+func TestFieldOriginSource(t *testing.T) {
+	origin := fieldOrigin{Window: utils.Window{StartIndex: 10, WindowLines: []string{
+		"Laboratory Test Results Report",
+		"Owner Name: John Smith",
+		"Patient Name: Pennie",
+		"Species: Canine (Dog)",
+		"Breed: Labrador Retriever (Mixed)",
+		"Sex: Female, Spayed",
+		"  - Weight: 65 lbs",
+		"Notes: the dog is a Lab mix, male owner present",
+	}}}
+
+	tests := []struct {
+		field    string
+		value    string
+		wantLine int64 // 0 when no line should be recorded
+	}{
+		{field: "name", value: "Pennie", wantLine: 13},
+		{field: "name", value: "pennie", wantLine: 13},
+		{field: "name", value: "John Smith"}, // Only under a patient label
+		{field: "possibleSpecies", value: "Canine", wantLine: 14},
+		{field: "possibleSpecies", value: "dog", wantLine: 14},
+		{field: "possibleBreed", value: "Labrador Retriever (Mixed)", wantLine: 15},
+		{field: "possibleBreed", value: "Lab"}, // Part of "Labrador", and the note isn't labeled as a breed
+		{field: "sex", value: "Female", wantLine: 16},
+		{field: "sex", value: "M"},
+		{field: "sex", value: "Male"},
+		{field: "weight", value: "65 lbs", wantLine: 17},
+		{field: "weight", value: "29.5 kg"},
+		{field: "color", value: "Golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field+" "+tt.value, func(t *testing.T) {
+			source := origin.source(0, tt.field, tt.value)
+
+			switch {
+			case tt.wantLine == 0 && source.LineNumber != nil:
+				t.Errorf("got line %d (%q), want none", *source.LineNumber, *source.LineText)
+			case tt.wantLine != 0 && source.LineNumber == nil:
+				t.Errorf("got no line, want %d", tt.wantLine)
+			case tt.wantLine != 0 && *source.LineNumber != tt.wantLine:
+				t.Errorf("got line %d, want %d", *source.LineNumber, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestMergeRecordsNoSourceForARecasedName(t *testing.T) {
+	var patients patientSet
+	origin := fieldOrigin{Window: utils.Window{WindowLines: []string{"Patient Name: Pennie"}}}
+
+	patients.merge(PatientResponse{Name: "Pennie"}, origin)
+	patients.merge(PatientResponse{Name: "PENNIE"}, origin)
+
+	if len(patients.sources) != 1 {
+		t.Errorf("got %d sources, want 1", len(patients.sources))
+	}
+	if patients.patients[0].Name != "Pennie" {
+		t.Errorf("name = %q, want the first spelling kept", patients.patients[0].Name)
+	}
+}
+
+func TestPatientSetMerge(t *testing.T) {
+	var patients patientSet
+	var origin fieldOrigin
+
+	// An unnamed first window is placed on the only patient once its name turns up
+	if index := patients.merge(PatientResponse{PossibleSpecies: "Canine", Weight: "65 lbs"}, origin); index != 0 {
+		t.Fatalf("unnamed first patient index = %d, want 0", index)
+	}
+	if index := patients.merge(PatientResponse{Name: "Pennie", PossibleBreed: "Labrador", Weight: "about 60"}, origin); index != 0 {
+		t.Fatalf("named patient index = %d, want 0", index)
+	}
+	if index := patients.merge(PatientResponse{Name: "Milo", PossibleSpecies: "Feline"}, origin); index != 1 {
+		t.Fatalf("second patient index = %d, want 1", index)
+	}
+	// With two patients an unnamed one can't be placed
+	if index := patients.merge(PatientResponse{Sex: "Male"}, origin); index != -1 {
+		t.Errorf("unnamed patient with two known index = %d, want -1", index)
+	}
+	if index := patients.merge(PatientResponse{Name: " pennie ", PossibleSpecies: "Dog"}, origin); index != 0 {
+		t.Errorf("repeated name index = %d, want 0", index)
+	}
+
+	if len(patients.patients) != 2 {
+		t.Fatalf("got %d patients, want 2", len(patients.patients))
+	}
+
+	pennie := patients.patients[0]
+	if pennie.Name != "Pennie" {
+		t.Errorf("name = %q, want Pennie", pennie.Name)
+	}
+	if pennie.PossibleSpecies == nil || len(*pennie.PossibleSpecies) != 2 {
+		t.Errorf("species = %v, want Canine and Dog", pennie.PossibleSpecies)
+	}
+	// "about 60" has no unit, so the 65 lbs reading stays
+	if pennie.Weight == nil || *pennie.Weight < 29.4 || *pennie.Weight > 29.5 {
+		t.Errorf("weight = %v, want 65 lbs in kg", pennie.Weight)
+	}
+	if pennie.Sex != nil {
+		t.Errorf("sex = %q, want the unplaced patient's sex dropped", *pennie.Sex)
+	}
+
+	// species, weight, name, breed, Milo's name and species, then the second species
+	if len(patients.sources) != 7 {
+		t.Errorf("got %d sources, want 7", len(patients.sources))
+	}
+}
+
+func TestFinalizePatients(t *testing.T) {
+	patients := []*models.Patient{{Name: "Milo"}, {Name: "Pennie"}}
+	documents := []models.AnalyzedDocument{
+		{PatientIndex: 1},
+		{PatientIndex: 0},
+		{PatientIndex: 1},
+		{PatientIndex: -1}, // Unassigned, goes to the main patient
+		{PatientIndex: 5},
+	}
+	sources := []models.PatientFieldSource{{PatientIndex: 0}, {PatientIndex: 1}}
+
+	ordered := finalizePatients(patients, documents, sources)
+
+	if ordered[0].Name != "Milo" || ordered[1].Name != "Pennie" {
+		t.Fatalf("order = %q, %q; want Milo (1 document plus the 2 unassigned) before Pennie (2)", ordered[0].Name, ordered[1].Name)
+	}
+
+	// Pennie has more documents, so she moves to the front and every index is remapped
+	patients = []*models.Patient{{Name: "Milo"}, {Name: "Pennie"}}
+	documents = []models.AnalyzedDocument{{PatientIndex: 1}, {PatientIndex: 1}, {PatientIndex: 0}}
+	sources = []models.PatientFieldSource{{PatientIndex: 0}, {PatientIndex: 1}}
+
+	ordered = finalizePatients(patients, documents, sources)
+
+	if ordered[0].Name != "Pennie" {
+		t.Errorf("primary patient = %q, want Pennie", ordered[0].Name)
+	}
+	wantDocuments := []int{0, 0, 1}
+	for i, want := range wantDocuments {
+		if documents[i].PatientIndex != want {
+			t.Errorf("documents[%d].PatientIndex = %d, want %d", i, documents[i].PatientIndex, want)
+		}
+	}
+	if sources[0].PatientIndex != 1 || sources[1].PatientIndex != 0 {
+		t.Errorf("source patient indexes = %d, %d; want 1, 0", sources[0].PatientIndex, sources[1].PatientIndex)
+	}
+
+	if ordered := finalizePatients(nil, nil, nil); len(ordered) != 1 {
+		t.Errorf("no patients finalized to %d, want one placeholder", len(ordered))
+	}
+}
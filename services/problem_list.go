@@ -0,0 +1,118 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"PennieAI/models"
+)
+
+// ProblemEvidence is a document that records a problem
+type ProblemEvidence struct {
+	DocumentID  int64      `json:"documentId"`
+	Title       *string    `json:"title"`
+	ServiceDate *time.Time `json:"serviceDate"`
+	Name        string     `json:"name"`   // As this document wrote it
+	Status      string     `json:"status"` // As of this document
+	StartLine   *int64     `json:"startLine"`
+	EndLine     *int64     `json:"endLine"`
+	SourceText  *string    `json:"sourceText"`
+}
+
+// ProblemListEntry is one problem of a patient's problem list with the documents that support it
+type ProblemListEntry struct {
+	Code           *string           `json:"code"` // Vocabulary code, nil for a problem no term matched
+	Name           string            `json:"name"`
+	Status         string            `json:"status"`
+	OnsetDate      *time.Time        `json:"onsetDate"`
+	LastRecordedOn *time.Time        `json:"lastRecordedOn"`
+	Documents      []ProblemEvidence `json:"documents"`
+}
+
+/*
+BuildProblemList merges the problems a patient's documents record into a problem list. Problems
+coded to the same vocabulary term are one entry, uncoded ones are merged by name. The onset is
+the earliest onset date or service date among its documents and the status is the one of the
+most recently dated document that records it. Active problems come first, each group by onset.
+*/
+func BuildProblemList(problems []models.Problem) []ProblemListEntry {
+	entries := []ProblemListEntry{}
+	index := map[string]int{}
+	statusDates := map[string]*time.Time{}
+
+	for _, problem := range problems {
+		key := "name:" + normalizeTerm(problem.Name)
+		name := problem.Name
+		if problem.ProblemTermID != nil && problem.TermName != nil {
+			key = "term:" + *problem.TermCode
+			name = *problem.TermName
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(entries)
+			index[key] = i
+			entries = append(entries, ProblemListEntry{Code: problem.TermCode, Name: name, Status: problem.Status})
+		}
+		entry := &entries[i]
+
+		if !hasProblemDocument(entry.Documents, problem.AnalyzedDocumentID) {
+			entry.Documents = append(entry.Documents, ProblemEvidence{
+				DocumentID:  problem.AnalyzedDocumentID,
+				Title:       problem.DocumentTitle,
+				ServiceDate: problem.DocumentServiceDate,
+				Name:        problem.Name,
+				Status:      problem.Status,
+				StartLine:   problem.StartLine,
+				EndLine:     problem.EndLine,
+				SourceText:  problem.SourceText,
+			})
+		}
+
+		for _, date := range []*time.Time{problem.OnsetDate, problem.DocumentServiceDate} {
+			if date != nil && (entry.OnsetDate == nil || date.Before(*entry.OnsetDate)) {
+				entry.OnsetDate = date
+			}
+		}
+		if date := problem.DocumentServiceDate; date != nil {
+			if entry.LastRecordedOn == nil || date.After(*entry.LastRecordedOn) {
+				entry.LastRecordedOn = date
+			}
+			// Undated documents only decide the status until a dated one records the problem
+			if statusDates[key] == nil || !date.Before(*statusDates[key]) {
+				entry.Status = problem.Status
+				statusDates[key] = date
+			}
+		} else if statusDates[key] == nil {
+			entry.Status = problem.Status
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		activeA := entries[a].Status == models.ProblemStatusActive
+		activeB := entries[b].Status == models.ProblemStatusActive
+		if activeA != activeB {
+			return activeA
+		}
+		onsetA, onsetB := entries[a].OnsetDate, entries[b].OnsetDate
+		if onsetA == nil || onsetB == nil {
+			return onsetA != nil && onsetB == nil
+		}
+		if !onsetA.Equal(*onsetB) {
+			return onsetA.Before(*onsetB)
+		}
+		return strings.ToLower(entries[a].Name) < strings.ToLower(entries[b].Name)
+	})
+
+	return entries
+}
+
+func hasProblemDocument(documents []ProblemEvidence, documentID int64) bool {
+	for _, document := range documents {
+		if document.DocumentID == documentID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// problemsResponse is the shape prompts.ProblemExtractionPrompt asks for
+type problemsResponse struct {
+	Problems []problemResponse `json:"problems"`
+}
+
+type problemResponse struct {
+	Name      lenientString `json:"name" description:"short clinical name without qualifiers, e.g. Hip dysplasia"`
+	Status    lenientString `json:"status" enum:"active,resolved"`
+	OnsetDate lenientString `json:"onset_date" description:"yyyy-MM-dd if the record says when the problem started, otherwise empty"`
+	StartLine lenientInt    `json:"start_line" description:"first line that records the problem"`
+	EndLine   lenientInt    `json:"end_line" description:"last line that records the problem"`
+}
+
+var problemsSchema = &ResponseSchema{
+	Name:   "problems",
+	Schema: utils.JSONSchemaFor(problemsResponse{}),
+}
+
+// ExtractProblems asks the AI service which diagnoses a saved document records and stores them
+// for the document's patient, coded against the problem vocabulary, replacing what an earlier
+// extraction found
+func ExtractProblems(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	terms, err := repository.GetProblemTerms()
+	if err != nil {
+		return err
+	}
+
+	var decoded problemsResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.ProblemExtractionPrompt, problemsSchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var problems []models.Problem
+	for _, item := range decoded.Problems {
+		name := strings.TrimSpace(string(item.Name))
+		if name == "" {
+			continue
+		}
+
+		status := models.ProblemStatusActive
+		if strings.EqualFold(strings.TrimSpace(string(item.Status)), models.ProblemStatusResolved) {
+			status = models.ProblemStatusResolved
+		}
+
+		problem := models.Problem{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			Name:               name,
+			Status:             status,
+			OnsetDate:          parseExtractedDate(item.OnsetDate),
+			InferenceID:        inferenceID,
+		}
+		if term := MatchProblemTerm(name, terms); term != nil {
+			problem.ProblemTermID = &term.ID
+		}
+		problem.StartLine, problem.EndLine, problem.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		problems = append(problems, problem)
+	}
+
+	return repository.ReplaceDocumentProblems(document.ID, problems)
+}
+
+/*
+MatchProblemTerm finds the vocabulary term for a problem name. A name equal to a term's code,
+name or one of its synonyms matches that term; otherwise the term whose name or synonym appears
+as whole words in the problem name wins, the longest one if several do ("allergic dermatitis
+with pyoderma" is pyoderma only if "allergic dermatitis" isn't a term). Returns nil if none match.
+*/
+func MatchProblemTerm(name string, terms []models.ProblemTerm) *models.ProblemTerm {
+	normalized := normalizeTerm(name)
+	if normalized == "" {
+		return nil
+	}
+
+	var best *models.ProblemTerm
+	bestLength := 0
+	for i := range terms {
+		candidates := append([]string{terms[i].Code, terms[i].Name}, terms[i].Synonyms...)
+		for _, candidate := range candidates {
+			candidate = normalizeTerm(candidate)
+			if candidate == "" {
+				continue
+			}
+			if candidate == normalized {
+				return &terms[i]
+			}
+			if len(candidate) > bestLength && strings.Contains(" "+normalized+" ", " "+candidate+" ") {
+				best = &terms[i]
+				bestLength = len(candidate)
+			}
+		}
+	}
+	return best
+}
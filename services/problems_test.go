@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"PennieAI/models"
+)
+
+func TestMatchProblemTerm(t *testing.T) {
+	terms := []models.ProblemTerm{
+		{Code: "allergic_dermatitis", Name: "Allergic Dermatitis", Synonyms: []string{"atopy", "atopic dermatitis"}},
+		{Code: "dermatitis", Name: "Dermatitis"},
+		{Code: "pyoderma", Name: "Pyoderma"},
+		{Code: "otitis_externa", Name: "Otitis Externa", Synonyms: []string{"ear infection"}},
+		{Code: "ckd", Name: "Chronic Kidney Disease", Synonyms: []string{"CKD"}},
+	}
+
+	tests := []struct {
+		name string
+		want string // Code of the matched term, "" for none
+	}{
+		{"Allergic dermatitis", "allergic_dermatitis"},
+		{"allergic_dermatitis", "allergic_dermatitis"},
+		{"Atopy", "allergic_dermatitis"},
+		{"CKD (IRIS stage 2)", "ckd"},
+		{"Left ear infection, chronic", "otitis_externa"},
+		// The longest term in the name wins
+		{"Allergic dermatitis with secondary pyoderma", "allergic_dermatitis"},
+		{"Dermatitis with pyoderma", "dermatitis"},
+		{"Otitis media with pyoderma", "pyoderma"},
+		// Only whole words
+		{"Pyodermatitis", ""},
+		{"Hyperthyroidism", ""},
+		{"  ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchProblemTerm(tt.name, terms)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("MatchProblemTerm(%q) = %q, want no match", tt.name, got.Code)
+			case tt.want != "" && got == nil:
+				t.Errorf("MatchProblemTerm(%q) = no match, want %q", tt.name, tt.want)
+			case tt.want != "" && got.Code != tt.want:
+				t.Errorf("MatchProblemTerm(%q) = %q, want %q", tt.name, got.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"PennieAI/models"
+)
+
+// LinkedProcedureDocument is a later document about a procedure: a post-operative check or its pathology report
+type LinkedProcedureDocument struct {
+	DocumentID  int64      `json:"documentId"`
+	Title       *string    `json:"title"`
+	ServiceDate *time.Time `json:"serviceDate"`
+	Role        string     `json:"role"`
+	StartLine   *int64     `json:"startLine"`
+	EndLine     *int64     `json:"endLine"`
+	SourceText  *string    `json:"sourceText"`
+}
+
+// ProcedureHistoryEntry is a procedure with the documents that followed up on it
+type ProcedureHistoryEntry struct {
+	models.Procedure
+	LinkedDocuments []LinkedProcedureDocument `json:"linkedDocuments"`
+}
+
+// Words that say nothing about which procedure a name means
+var procedureNameStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "for": true, "of": true, "on": true, "the": true, "to": true, "with": true,
+	"surgery": true, "surgical": true, "procedure": true, "operation": true, "post": true, "operative": true,
+}
+
+/*
+BuildProcedureHistory links the follow-up references of a patient's documents to the procedures
+they are about. A reference matches a procedure whose name shares at least half the words of the
+shorter name, performed on the date the reference states or, without one, no later than the
+referring document. The best matching name wins, then the most recent procedure. References that
+match nothing are returned separately.
+*/
+func BuildProcedureHistory(procedures []models.Procedure, followUps []models.ProcedureFollowUp) ([]ProcedureHistoryEntry, []models.ProcedureFollowUp) {
+	history := make([]ProcedureHistoryEntry, len(procedures))
+	for i, procedure := range procedures {
+		history[i] = ProcedureHistoryEntry{Procedure: procedure, LinkedDocuments: []LinkedProcedureDocument{}}
+	}
+
+	unlinked := []models.ProcedureFollowUp{}
+	for _, followUp := range followUps {
+		best := -1
+		bestScore := 0.0
+		for i, procedure := range procedures {
+			if procedure.AnalyzedDocumentID == followUp.AnalyzedDocumentID || !followUpDateFits(followUp, procedure) {
+				continue
+			}
+
+			score := procedureNameOverlap(followUp.ProcedureName, procedure.Name)
+			if score < 0.5 {
+				continue
+			}
+			if best < 0 || score > bestScore || (score == bestScore && performedAfter(procedure, procedures[best])) {
+				best = i
+				bestScore = score
+			}
+		}
+
+		if best < 0 {
+			unlinked = append(unlinked, followUp)
+			continue
+		}
+		history[best].LinkedDocuments = linkProcedureDocument(history[best].LinkedDocuments, followUp)
+	}
+
+	return history, unlinked
+}
+
+// followUpDateFits reports whether the procedure could be the one the follow-up refers to by date
+func followUpDateFits(followUp models.ProcedureFollowUp, procedure models.Procedure) bool {
+	if procedure.PerformedOn == nil {
+		return true
+	}
+	if followUp.ProcedureDate != nil {
+		return followUp.ProcedureDate.Equal(*procedure.PerformedOn)
+	}
+	if followUp.DocumentServiceDate != nil {
+		return !followUp.DocumentServiceDate.Before(*procedure.PerformedOn)
+	}
+	return true
+}
+
+func performedAfter(a models.Procedure, b models.Procedure) bool {
+	return a.PerformedOn != nil && (b.PerformedOn == nil || a.PerformedOn.After(*b.PerformedOn))
+}
+
+// procedureNameOverlap is the share of the shorter name's words the other name has too
+func procedureNameOverlap(a string, b string) float64 {
+	wordsA, wordsB := procedureNameWords(a), procedureNameWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	if len(wordsA) > len(wordsB) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA))
+}
+
+func procedureNameWords(name string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.Fields(normalizeTerm(name)) {
+		if !procedureNameStopWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// linkProcedureDocument adds the follow-up's document, once; a document that both follows up and reports pathology is listed as pathology
+func linkProcedureDocument(documents []LinkedProcedureDocument, followUp models.ProcedureFollowUp) []LinkedProcedureDocument {
+	for i := range documents {
+		if documents[i].DocumentID == followUp.AnalyzedDocumentID {
+			if followUp.Role == models.ProcedureFollowUpRolePathology {
+				documents[i].Role = models.ProcedureFollowUpRolePathology
+			}
+			return documents
+		}
+	}
+
+	return append(documents, LinkedProcedureDocument{
+		DocumentID:  followUp.AnalyzedDocumentID,
+		Title:       followUp.DocumentTitle,
+		ServiceDate: followUp.DocumentServiceDate,
+		Role:        followUp.Role,
+		StartLine:   followUp.StartLine,
+		EndLine:     followUp.EndLine,
+		SourceText:  followUp.SourceText,
+	})
+}
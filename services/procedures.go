@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// proceduresResponse is the shape prompts.ProcedureExtractionPrompt asks for
+type proceduresResponse struct {
+	Procedures []procedureResponse         `json:"procedures"`
+	FollowUps  []procedureFollowUpResponse `json:"follow_ups"`
+}
+
+type procedureResponse struct {
+	Name          lenientString `json:"name"`
+	PerformedDate lenientString `json:"performed_date" description:"yyyy-MM-dd, empty if not stated"`
+	Surgeon       lenientString `json:"surgeon"`
+	Anesthesia    lenientString `json:"anesthesia" description:"anesthesia notes as the record gives them, empty if none"`
+	StartLine     lenientInt    `json:"start_line" description:"first line that describes the procedure"`
+	EndLine       lenientInt    `json:"end_line" description:"last line that describes the procedure"`
+}
+
+type procedureFollowUpResponse struct {
+	ProcedureName lenientString `json:"procedure_name" description:"the earlier procedure, as this record names it"`
+	ProcedureDate lenientString `json:"procedure_date" description:"yyyy-MM-dd if the record states when it was performed, otherwise empty"`
+	Role          lenientString `json:"role" enum:"follow_up,pathology"`
+	StartLine     lenientInt    `json:"start_line"`
+	EndLine       lenientInt    `json:"end_line"`
+}
+
+var proceduresSchema = &ResponseSchema{
+	Name:   "procedures",
+	Schema: utils.JSONSchemaFor(proceduresResponse{}),
+}
+
+// ExtractProcedures asks the AI service which procedures a saved document reports and which
+// earlier ones it follows up on, and stores both for the document's patient, replacing what an
+// earlier extraction found. Procedures without a date take the document's service date.
+func ExtractProcedures(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	var decoded proceduresResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.ProcedureExtractionPrompt, proceduresSchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var procedures []models.Procedure
+	for _, item := range decoded.Procedures {
+		name := strings.TrimSpace(string(item.Name))
+		if name == "" {
+			continue
+		}
+
+		procedure := models.Procedure{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			Name:               name,
+			PerformedOn:        parseExtractedDate(item.PerformedDate),
+			Surgeon:            optionalString(item.Surgeon),
+			Anesthesia:         optionalString(item.Anesthesia),
+			InferenceID:        inferenceID,
+		}
+		if procedure.PerformedOn == nil {
+			procedure.PerformedOn = document.ServiceDate
+		}
+		procedure.StartLine, procedure.EndLine, procedure.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		procedures = append(procedures, procedure)
+	}
+
+	var followUps []models.ProcedureFollowUp
+	for _, item := range decoded.FollowUps {
+		name := strings.TrimSpace(string(item.ProcedureName))
+		if name == "" {
+			continue
+		}
+
+		role := models.ProcedureFollowUpRoleFollowUp
+		if strings.EqualFold(strings.TrimSpace(string(item.Role)), models.ProcedureFollowUpRolePathology) {
+			role = models.ProcedureFollowUpRolePathology
+		}
+
+		followUp := models.ProcedureFollowUp{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			ProcedureName:      name,
+			ProcedureDate:      parseExtractedDate(item.ProcedureDate),
+			Role:               role,
+			InferenceID:        inferenceID,
+		}
+		followUp.StartLine, followUp.EndLine, followUp.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		followUps = append(followUps, followUp)
+	}
+
+	return repository.ReplaceDocumentProcedures(document.ID, procedures, followUps)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"PennieAI/models"
+)
+
+const (
+	spanMatchTolerance  = 2 // Spans whose start and end are both this close are the same document
+	maxExpectedGap      = 3 // The prompt tells the model to expect no more than 2-3 lines between documents
+	minTrimmedSpanLines = 3 // A span trimmed below this many lines by an overlap is dropped
+)
+
+// documentCandidate is one window's claim that a document spans StartLine-EndLine (1-based, inclusive)
+type documentCandidate struct {
+	Title        string
+	StartLine    int64
+	EndLine      int64
+	WindowIndex  int
+	CenterMargin int64 // Lines between the span and the nearest edge of the window that reported it
+	PatientIndex int   // Index into the analysis' patients, -1 if the window didn't say
+}
+
+// BoundaryGap is a run of lines no document claims that is longer than the prompt expects:
+// between two documents, or before the first or after the last one
+type BoundaryGap struct {
+	StartLine      int64  `json:"startLine"`
+	EndLine        int64  `json:"endLine"`
+	NumberOfLines  int64  `json:"numberOfLines"`
+	AfterDocument  string `json:"afterDocument"`  // Empty for a gap at the start of the file
+	BeforeDocument string `json:"beforeDocument"` // Empty for a gap at the end of the file
+}
+
+func (c documentCandidate) overlaps(other documentCandidate) bool {
+	return c.StartLine <= other.EndLine && other.StartLine <= c.EndLine
+}
+
+func (c documentCandidate) nearlyMatches(other documentCandidate) bool {
+	return absInt64(c.StartLine-other.StartLine) <= spanMatchTolerance &&
+		absInt64(c.EndLine-other.EndLine) <= spanMatchTolerance
+}
+
+/*
+reconcileBoundaries turns every window's document spans into one non-overlapping list.
+
+Candidates are considered from most to least centered in their window, since a document in
+the middle of a window is the one the model saw whole. A candidate that nearly matches an
+accepted span is merged into it. One that overlaps an accepted span is trimmed back to the
+lines nobody else claimed, or dropped if that leaves too little (or if it swallows the
+accepted span entirely); each such overlap is returned with how it was resolved. Spans
+reaching outside the file are clamped to it and inverted spans are dropped. Unclaimed runs longer than maxExpectedGap, between the resulting documents or at
+either end of the file, are reported so reviewers can look for a missed document.
+*/
+func reconcileBoundaries(candidates []documentCandidate, fileLines []string) ([]models.AnalyzedDocument, []BoundaryGap, []models.LineOverlap) {
+	ordered := make([]documentCandidate, len(candidates))
+	copy(ordered, candidates)
+
+	// Ties are broken on window and line so the result never depends on input order
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].CenterMargin != ordered[j].CenterMargin {
+			return ordered[i].CenterMargin > ordered[j].CenterMargin
+		}
+		if ordered[i].WindowIndex != ordered[j].WindowIndex {
+			return ordered[i].WindowIndex < ordered[j].WindowIndex
+		}
+		return ordered[i].StartLine < ordered[j].StartLine
+	})
+
+	var accepted []documentCandidate
+	overlaps := []models.LineOverlap{}
+
+	for _, candidate := range ordered {
+		// Line numbers come from the model, keep them inside the file
+		candidate.StartLine = max(candidate.StartLine, 1)
+		candidate.EndLine = min(candidate.EndLine, int64(len(fileLines)))
+		if candidate.EndLine < candidate.StartLine {
+			continue
+		}
+		reported := models.LineRange{StartLine: candidate.StartLine, EndLine: candidate.EndLine}
+
+		keep := true
+		duplicate := false
+		var candidateOverlaps []models.LineOverlap
+		for _, existing := range accepted {
+			if candidate.nearlyMatches(existing) {
+				// The same document seen by another window, not an overlap
+				keep = false
+				duplicate = true
+				break
+			}
+			if !candidate.overlaps(existing) {
+				continue
+			}
+
+			candidateOverlaps = append(candidateOverlaps, models.LineOverlap{
+				LineRange: models.LineRange{
+					StartLine: max(candidate.StartLine, existing.StartLine),
+					EndLine:   min(candidate.EndLine, existing.EndLine),
+				},
+				Document:       existing.Title,
+				Candidate:      candidate.Title,
+				CandidateRange: reported,
+				WindowIndex:    candidate.WindowIndex,
+			})
+
+			if candidate.StartLine < existing.StartLine && candidate.EndLine > existing.EndLine {
+				// Trimming would split the candidate in two, it most likely merged two documents
+				keep = false
+				break
+			}
+			if candidate.StartLine >= existing.StartLine {
+				candidate.StartLine = existing.EndLine + 1
+			} else {
+				candidate.EndLine = existing.StartLine - 1
+			}
+			if candidate.EndLine-candidate.StartLine+1 < minTrimmedSpanLines {
+				keep = false
+				break
+			}
+		}
+
+		if !duplicate {
+			resolution := models.OverlapDropped
+			if keep {
+				resolution = models.OverlapTrimmed
+			}
+			for _, overlap := range candidateOverlaps {
+				overlap.Resolution = resolution
+				overlaps = append(overlaps, overlap)
+			}
+		}
+		if keep {
+			accepted = append(accepted, candidate)
+		}
+	}
+
+	sort.SliceStable(overlaps, func(i, j int) bool {
+		return overlaps[i].StartLine < overlaps[j].StartLine
+	})
+
+	sort.Slice(accepted, func(i, j int) bool {
+		return accepted[i].StartLine < accepted[j].StartLine
+	})
+
+	documents := make([]models.AnalyzedDocument, 0, len(accepted))
+	var gaps []BoundaryGap
+
+	// Blank lines at the ends of the file don't count towards a leading or trailing gap
+	firstContentLine, lastContentLine := int64(1), int64(len(fileLines))
+	for firstContentLine <= lastContentLine && strings.TrimSpace(fileLines[firstContentLine-1]) == "" {
+		firstContentLine++
+	}
+	for lastContentLine >= firstContentLine && strings.TrimSpace(fileLines[lastContentLine-1]) == "" {
+		lastContentLine--
+	}
+
+	leadingEnd := lastContentLine
+	if len(accepted) > 0 {
+		leadingEnd = accepted[0].StartLine - 1
+	}
+	if gapLines := leadingEnd - firstContentLine + 1; gapLines > maxExpectedGap {
+		gap := BoundaryGap{StartLine: firstContentLine, EndLine: leadingEnd, NumberOfLines: gapLines}
+		if len(accepted) > 0 {
+			gap.BeforeDocument = accepted[0].Title
+		}
+		gaps = append(gaps, gap)
+	}
+
+	for i, span := range accepted {
+		documentLines := fileLines[span.StartLine-1 : span.EndLine]
+		documentType, confidence := ClassifyDocument(span.Title, documentLines)
+		documents = append(documents, models.AnalyzedDocument{
+			Title:                  span.Title,
+			Content:                strings.Join(documentLines, "\n"),
+			StartLine:              span.StartLine,
+			EndLine:                span.EndLine,
+			NumberOfLines:          span.EndLine - span.StartLine + 1,
+			WindowLines:            documentLines,
+			PatientIndex:           span.PatientIndex,
+			DocumentType:           documentType,
+			DocumentTypeConfidence: confidence,
+			ServiceDate:            ExtractServiceDate(documentLines),
+		})
+
+		if i == 0 {
+			continue
+		}
+		previous := accepted[i-1]
+		if gapLines := span.StartLine - previous.EndLine - 1; gapLines > maxExpectedGap {
+			gaps = append(gaps, BoundaryGap{
+				StartLine:      previous.EndLine + 1,
+				EndLine:        span.StartLine - 1,
+				NumberOfLines:  gapLines,
+				AfterDocument:  previous.Title,
+				BeforeDocument: span.Title,
+			})
+		}
+	}
+
+	if len(accepted) > 0 {
+		last := accepted[len(accepted)-1]
+		if gapLines := lastContentLine - last.EndLine; gapLines > maxExpectedGap {
+			gaps = append(gaps, BoundaryGap{
+				StartLine:     last.EndLine + 1,
+				EndLine:       lastContentLine,
+				NumberOfLines: gapLines,
+				AfterDocument: last.Title,
+			})
+		}
+	}
+
+	return documents, gaps, overlaps
+}
+
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
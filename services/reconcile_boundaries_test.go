@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestReconcileBoundaries(t *testing.T) {
+	fileLines := make([]string, 20)
+	for i := range fileLines {
+		fileLines[i] = fmt.Sprintf("line %d", i+1)
+	}
+
+	tests := []struct {
+		name       string
+		candidates []documentCandidate
+		wantSpans  [][2]int64
+		wantGaps   [][2]int64
+		// "<start>-<end> <resolution>" of each resolved overlap
+		wantOverlaps []string
+	}{
+		{
+			name: "overlap is trimmed from the less centered span",
+			candidates: []documentCandidate{
+				{Title: "B", StartLine: 8, EndLine: 20, CenterMargin: 2},
+				{Title: "A", StartLine: 1, EndLine: 10, CenterMargin: 5},
+			},
+			wantSpans:    [][2]int64{{1, 10}, {11, 20}},
+			wantOverlaps: []string{"8-10 trimmed"},
+		},
+		{
+			name: "nearly matching spans merge into the more centered one",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: 2, EndLine: 19, CenterMargin: 1},
+				{Title: "A", StartLine: 1, EndLine: 20, CenterMargin: 5},
+			},
+			wantSpans: [][2]int64{{1, 20}},
+		},
+		{
+			name: "span swallowing an accepted one is dropped",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: 5, EndLine: 15, CenterMargin: 5},
+				{Title: "B", StartLine: 1, EndLine: 20, CenterMargin: 1},
+			},
+			wantSpans:    [][2]int64{{5, 15}},
+			wantGaps:     [][2]int64{{1, 4}, {16, 20}},
+			wantOverlaps: []string{"5-15 dropped"},
+		},
+		{
+			name: "span contained in an accepted one is dropped",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: 1, EndLine: 20, CenterMargin: 5},
+				{Title: "B", StartLine: 5, EndLine: 10, CenterMargin: 1},
+			},
+			wantSpans:    [][2]int64{{1, 20}},
+			wantOverlaps: []string{"5-10 dropped"},
+		},
+		{
+			name: "span trimmed below the minimum is dropped",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: 1, EndLine: 10, CenterMargin: 5},
+				{Title: "B", StartLine: 9, EndLine: 12, CenterMargin: 1},
+				{Title: "C", StartLine: 13, EndLine: 20, CenterMargin: 5},
+			},
+			wantSpans:    [][2]int64{{1, 10}, {13, 20}},
+			wantOverlaps: []string{"9-10 dropped"},
+		},
+		{
+			name: "spans outside the file are clamped",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: -3, EndLine: 10, CenterMargin: 5},
+				{Title: "B", StartLine: 11, EndLine: 40, CenterMargin: 5},
+			},
+			wantSpans: [][2]int64{{1, 10}, {11, 20}},
+		},
+		{
+			name: "inverted spans and spans past the end are dropped",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: 1, EndLine: 20, CenterMargin: 1},
+				{Title: "B", StartLine: 12, EndLine: 4, CenterMargin: 5},
+				{Title: "C", StartLine: 25, EndLine: 30, CenterMargin: 5},
+			},
+			wantSpans: [][2]int64{{1, 20}},
+		},
+		{
+			name: "gaps longer than expected are reported",
+			candidates: []documentCandidate{
+				{Title: "A", StartLine: 1, EndLine: 5, CenterMargin: 5},
+				{Title: "B", StartLine: 10, EndLine: 14, CenterMargin: 5},
+				{Title: "C", StartLine: 16, EndLine: 20, CenterMargin: 5},
+			},
+			wantSpans: [][2]int64{{1, 5}, {10, 14}, {16, 20}},
+			wantGaps:  [][2]int64{{6, 9}},
+		},
+		{
+			name:     "no documents leaves the whole file as a gap",
+			wantGaps: [][2]int64{{1, 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents, gaps, overlaps := reconcileBoundaries(tt.candidates, fileLines)
+
+			var spans [][2]int64
+			for _, document := range documents {
+				spans = append(spans, [2]int64{document.StartLine, document.EndLine})
+				if document.NumberOfLines != int64(len(document.WindowLines)) {
+					t.Errorf("document %q has %d lines but %d window lines", document.Title, document.NumberOfLines, len(document.WindowLines))
+				}
+			}
+			if !reflect.DeepEqual(spans, tt.wantSpans) {
+				t.Errorf("spans = %v, want %v", spans, tt.wantSpans)
+			}
+
+			var gapSpans [][2]int64
+			for _, gap := range gaps {
+				gapSpans = append(gapSpans, [2]int64{gap.StartLine, gap.EndLine})
+			}
+			if !reflect.DeepEqual(gapSpans, tt.wantGaps) {
+				t.Errorf("gaps = %v, want %v", gapSpans, tt.wantGaps)
+			}
+
+			var overlapSpans []string
+			for _, overlap := range overlaps {
+				overlapSpans = append(overlapSpans, fmt.Sprintf("%d-%d %s", overlap.StartLine, overlap.EndLine, overlap.Resolution))
+			}
+			if !reflect.DeepEqual(overlapSpans, tt.wantOverlaps) {
+				t.Errorf("overlaps = %v, want %v", overlapSpans, tt.wantOverlaps)
+			}
+		})
+	}
+}
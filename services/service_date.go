@@ -0,0 +1,63 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// "Examination Date: March 5, 2011", "Date: 2011-03-05", "Date of Registration: March 5, 2011"
+	serviceDateLinePattern = regexp.MustCompile(`^(?:[A-Z][A-Za-z-]*(?: [A-Z][A-Za-z-]*)* )?Date(?: of ([A-Za-z ]+))?:\s*(.+)$`)
+	// The date inside a value that may have more text around it
+	datePattern   = regexp.MustCompile(`(?i)\b(?:(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.? \d{1,2}(?:st|nd|rd|th)?,? \d{4}|\d{1,2} (?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]* \d{4}|\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4})\b`)
+	ordinalSuffix = regexp.MustCompile(`(?i)(\d)(st|nd|rd|th)\b`)
+	septAbbrev    = regexp.MustCompile(`(?i)\bsept\b`)
+)
+
+// Formats seen in our records. Numeric dates are read US style, month first.
+var serviceDateLayouts = []string{
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"Jan. 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"2006-01-02",
+	"1/2/2006",
+}
+
+/*
+ExtractServiceDate finds the date the care in a document happened: the first "<Kind> Date:"
+(or "Date of <kind>:") line whose value parses as a date. Dates of birth are skipped, they
+describe the patient rather than the visit. Returns nil for undated documents.
+*/
+func ExtractServiceDate(documentLines []string) *time.Time {
+	for _, line := range documentLines {
+		match := serviceDateLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || strings.EqualFold(strings.TrimSpace(match[1]), "birth") {
+			continue
+		}
+		if date, ok := parseServiceDate(match[2]); ok {
+			return &date
+		}
+	}
+	return nil
+}
+
+func parseServiceDate(value string) (time.Time, bool) {
+	dateText := datePattern.FindString(value)
+	if dateText == "" {
+		return time.Time{}, false
+	}
+	dateText = ordinalSuffix.ReplaceAllString(dateText, "$1")
+	dateText = septAbbrev.ReplaceAllString(dateText, "Sep")
+
+	for _, layout := range serviceDateLayouts {
+		if date, err := time.Parse(layout, dateText); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtractServiceDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  *time.Time
+	}{
+		{"header date", []string{"Wellness Exam", "Examination Date: March 5, 2011"}, testDate(2011, time.March, 5)},
+		{"plain date", []string{"Date: 2011-03-05"}, testDate(2011, time.March, 5)},
+		{"date of kind", []string{"Date of Registration: 5 March 2011"}, testDate(2011, time.March, 5)},
+		{"ordinal", []string{"Visit Date: May 21st, 2019"}, testDate(2019, time.May, 21)},
+		{"sept", []string{"Email Date: Sept. 3, 2020 at 10:15 AM"}, testDate(2020, time.September, 3)},
+		{"us numeric", []string{"Report Date: 4/7/2022"}, testDate(2022, time.April, 7)},
+		{"birth date skipped", []string{"Date of Birth: January 1, 2010", "Visit Date: 6/15/2015"}, testDate(2015, time.June, 15)},
+		{"unparseable value skipped", []string{"Visit Date: pending", "Date: 2015-06-16"}, testDate(2015, time.June, 16)},
+		{"not a label", []string{"Discussed the due date: March 5, 2011"}, nil},
+		{"undated", []string{"Phone call with owner"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractServiceDate(tt.lines)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("got %v, want nil", got.Format("2006-01-02"))
+			case tt.want != nil && got == nil:
+				t.Errorf("got nil, want %v", tt.want.Format("2006-01-02"))
+			case tt.want != nil && !got.Equal(*tt.want):
+				t.Errorf("got %v, want %v", got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
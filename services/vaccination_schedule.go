@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"PennieAI/models"
+)
+
+// VaccineSchedule is how often one vaccine should be repeated for a species
+type VaccineSchedule struct {
+	Vaccine        string `json:"vaccine"` // Key from NormalizeVaccine
+	Name           string `json:"name"`
+	IntervalMonths int    `json:"intervalMonths"`
+	Core           bool   `json:"core"` // Core vaccines are overdue even if the patient never had them
+}
+
+/*
+defaultVaccinationSchedules are used unless VACCINATION_SCHEDULES_FILE points at a JSON file of
+the same shape (species -> schedules), which replaces them. Intervals are the conservative
+yearly boosters; clinics on three-year rabies or DA2PP protocols should configure their own.
+*/
+var defaultVaccinationSchedules = map[string][]VaccineSchedule{
+	"canine": {
+		{Vaccine: "rabies", Name: "Rabies", IntervalMonths: 12, Core: true},
+		{Vaccine: "da2pp", Name: "DA2PP", IntervalMonths: 12, Core: true},
+		{Vaccine: "leptospirosis", Name: "Leptospirosis", IntervalMonths: 12},
+		{Vaccine: "bordetella", Name: "Bordetella", IntervalMonths: 12},
+		{Vaccine: "canine_influenza", Name: "Canine Influenza", IntervalMonths: 12},
+		{Vaccine: "lyme", Name: "Lyme", IntervalMonths: 12},
+	},
+	"feline": {
+		{Vaccine: "rabies", Name: "Rabies", IntervalMonths: 12, Core: true},
+		{Vaccine: "fvrcp", Name: "FVRCP", IntervalMonths: 12, Core: true},
+		{Vaccine: "felv", Name: "FeLV", IntervalMonths: 12},
+	},
+}
+
+// speciesKeywords map the species an analysis reads ("Canine (Dog)", "Domestic Shorthair Cat") to a schedule key
+var speciesKeywords = []struct {
+	species  string
+	keywords []string
+}{
+	{"canine", []string{"canine", "dog", "canis"}},
+	{"feline", []string{"feline", "cat", "felis"}},
+}
+
+var (
+	vaccinationSchedules     map[string][]VaccineSchedule
+	vaccinationSchedulesOnce sync.Once
+)
+
+// VaccinationSchedules returns the configured schedules per species
+func VaccinationSchedules() map[string][]VaccineSchedule {
+	vaccinationSchedulesOnce.Do(func() {
+		vaccinationSchedules = defaultVaccinationSchedules
+
+		path := os.Getenv("VACCINATION_SCHEDULES_FILE")
+		if path == "" {
+			return
+		}
+		schedules, err := loadVaccinationSchedules(path)
+		if err != nil {
+			log.Printf("⚠️  Failed to load vaccination schedules from %s, using the defaults: %v", path, err)
+			return
+		}
+		vaccinationSchedules = schedules
+		log.Printf("Loaded vaccination schedules for %d species from %s", len(schedules), path)
+	})
+	return vaccinationSchedules
+}
+
+func loadVaccinationSchedules(path string) (map[string][]VaccineSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules map[string][]VaccineSchedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, err
+	}
+	for species, entries := range schedules {
+		for _, entry := range entries {
+			if entry.Vaccine == "" || entry.IntervalMonths <= 0 {
+				return nil, fmt.Errorf("%s: every schedule needs a vaccine and a positive intervalMonths", species)
+			}
+		}
+	}
+	return schedules, nil
+}
+
+// ScheduleSpecies returns the schedule key for the first of the patient's possible species that has one, or ""
+func ScheduleSpecies(possibleSpecies []string) string {
+	for _, species := range possibleSpecies {
+		lower := strings.ToLower(species)
+		for _, entry := range speciesKeywords {
+			for _, keyword := range entry.keywords {
+				if strings.Contains(lower, keyword) {
+					return entry.species
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Where a scheduled vaccine stands
+const (
+	VaccineOverdue  = "overdue"
+	VaccineUpcoming = "upcoming"
+	VaccineCurrent  = "current"
+)
+
+// VaccineStatus is one scheduled vaccine for a patient: when it was last given and when it's due next
+type VaccineStatus struct {
+	VaccineSchedule
+	Status             string     `json:"status"`
+	LastAdministeredOn *time.Time `json:"lastAdministeredOn"` // Nil for a core vaccine never given
+	NextDueOn          *time.Time `json:"nextDueOn"`          // Nil for a core vaccine never given, which is due now
+	DueFromRecord      bool       `json:"dueFromRecord"`      // NextDueOn was stated in the record rather than computed from the interval
+}
+
+// VaccinationReport sorts a patient's scheduled vaccines by status
+type VaccinationReport struct {
+	Species  string          `json:"species"` // Schedule used, "" if the patient's species has none
+	Overdue  []VaccineStatus `json:"overdue"`
+	Upcoming []VaccineStatus `json:"upcoming"`
+	Current  []VaccineStatus `json:"current"`
+}
+
+/*
+EvaluateVaccinations compares a patient's vaccinations with the schedule for its species. A
+vaccine is due at the next due date its latest record states, otherwise its interval after it
+was last given. It is upcoming when that is within upcomingDays of today. Non-core vaccines the
+patient never had aren't listed.
+*/
+func EvaluateVaccinations(possibleSpecies []string, vaccinations []models.Vaccination, today time.Time, upcomingDays int) VaccinationReport {
+	report := VaccinationReport{
+		Species:  ScheduleSpecies(possibleSpecies),
+		Overdue:  []VaccineStatus{},
+		Upcoming: []VaccineStatus{},
+		Current:  []VaccineStatus{},
+	}
+
+	latest := map[string]models.Vaccination{}
+	for _, vaccination := range vaccinations {
+		if vaccination.AdministeredOn == nil {
+			continue
+		}
+		// Normalized again so rows stored before a keyword was fixed still count
+		vaccine := NormalizeVaccine(vaccination.Name)
+		if previous, ok := latest[vaccine]; !ok || vaccination.AdministeredOn.After(*previous.AdministeredOn) {
+			latest[vaccine] = vaccination
+		}
+	}
+
+	today = today.Truncate(24 * time.Hour)
+	upcomingBefore := today.AddDate(0, 0, upcomingDays)
+
+	for _, schedule := range VaccinationSchedules()[report.Species] {
+		status := VaccineStatus{VaccineSchedule: schedule}
+
+		last, given := latest[schedule.Vaccine]
+		switch {
+		case !given && !schedule.Core:
+			continue
+		case !given:
+			status.Status = VaccineOverdue
+		default:
+			status.LastAdministeredOn = last.AdministeredOn
+			nextDue := last.AdministeredOn.AddDate(0, schedule.IntervalMonths, 0)
+			if last.NextDueOn != nil {
+				nextDue = *last.NextDueOn
+				status.DueFromRecord = true
+			}
+			status.NextDueOn = &nextDue
+
+			switch {
+			case nextDue.Before(today):
+				status.Status = VaccineOverdue
+			case nextDue.Before(upcomingBefore):
+				status.Status = VaccineUpcoming
+			default:
+				status.Status = VaccineCurrent
+			}
+		}
+
+		switch status.Status {
+		case VaccineOverdue:
+			report.Overdue = append(report.Overdue, status)
+		case VaccineUpcoming:
+			report.Upcoming = append(report.Upcoming, status)
+		default:
+			report.Current = append(report.Current, status)
+		}
+	}
+
+	// Most pressing first; never given sorts before everything
+	for _, statuses := range [][]VaccineStatus{report.Overdue, report.Upcoming, report.Current} {
+		sort.SliceStable(statuses, func(i, j int) bool {
+			if statuses[i].NextDueOn == nil || statuses[j].NextDueOn == nil {
+				return statuses[i].NextDueOn == nil && statuses[j].NextDueOn != nil
+			}
+			return statuses[i].NextDueOn.Before(*statuses[j].NextDueOn)
+		})
+	}
+
+	return report
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"PennieAI/models"
+)
+
+func testDate(year int, month time.Month, day int) *time.Time {
+	value := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &value
+}
+
+func TestEvaluateVaccinations(t *testing.T) {
+	today := *testDate(2024, time.June, 1)
+
+	tests := []struct {
+		name         string
+		species      []string
+		vaccinations []models.Vaccination
+		// Vaccine key to its status and next due date ("" when never given)
+		want map[string][2]string
+	}{
+		{
+			name:    "due dates from the interval",
+			species: []string{"Canine (Dog)"},
+			vaccinations: []models.Vaccination{
+				{Name: "Rabies", AdministeredOn: testDate(2023, time.January, 10)},
+				{Name: "Rabies", AdministeredOn: testDate(2023, time.July, 15)},
+				{Name: "DA2PP", AdministeredOn: testDate(2023, time.June, 20)},
+				{Name: "Bordetella", AdministeredOn: testDate(2024, time.March, 1)},
+			},
+			want: map[string][2]string{
+				"rabies":     {VaccineUpcoming, "2024-07-15"},
+				"da2pp":      {VaccineUpcoming, "2024-06-20"},
+				"bordetella": {VaccineCurrent, "2025-03-01"},
+			},
+		},
+		{
+			name:    "due date stated in the record wins",
+			species: []string{"Canine"},
+			vaccinations: []models.Vaccination{
+				{Name: "Rabies 3-year", AdministeredOn: testDate(2023, time.January, 10), NextDueOn: testDate(2026, time.January, 10)},
+				{Name: "DHPP", AdministeredOn: testDate(2022, time.May, 1)},
+			},
+			want: map[string][2]string{
+				"rabies": {VaccineCurrent, "2026-01-10"},
+				"da2pp":  {VaccineOverdue, "2023-05-01"},
+			},
+		},
+		{
+			name:         "core vaccines never given are overdue",
+			species:      []string{"Dog"},
+			vaccinations: nil,
+			want: map[string][2]string{
+				"rabies": {VaccineOverdue, ""},
+				"da2pp":  {VaccineOverdue, ""},
+			},
+		},
+		{
+			name:    "feline distemper counts as FVRCP",
+			species: []string{"Domestic Shorthair Cat"},
+			vaccinations: []models.Vaccination{
+				// Stored with the key an older normalizer gave it
+				{Vaccine: "da2pp", Name: "FVRCP (feline distemper)", AdministeredOn: testDate(2024, time.February, 1)},
+				{Name: "Rabies", AdministeredOn: testDate(2024, time.February, 1)},
+			},
+			want: map[string][2]string{
+				"fvrcp":  {VaccineCurrent, "2025-02-01"},
+				"rabies": {VaccineCurrent, "2025-02-01"},
+			},
+		},
+		{
+			name:         "species without a schedule",
+			species:      []string{"Bearded Dragon"},
+			vaccinations: []models.Vaccination{{Name: "Rabies", AdministeredOn: testDate(2020, time.January, 1)}},
+			want:         map[string][2]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := EvaluateVaccinations(tt.species, tt.vaccinations, today, 60)
+
+			got := map[string][2]string{}
+			for _, statuses := range [][]VaccineStatus{report.Overdue, report.Upcoming, report.Current} {
+				for _, status := range statuses {
+					nextDue := ""
+					if status.NextDueOn != nil {
+						nextDue = status.NextDueOn.Format("2006-01-02")
+					}
+					got[status.Vaccine] = [2]string{status.Status, nextDue}
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for vaccine, want := range tt.want {
+				if got[vaccine] != want {
+					t.Errorf("%s = %v, want %v", vaccine, got[vaccine], want)
+				}
+			}
+		})
+	}
+}
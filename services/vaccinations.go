@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/prompts"
+	"PennieAI/repository"
+	"PennieAI/utils"
+)
+
+// vaccinationsResponse is the shape prompts.VaccinationExtractionPrompt asks for
+type vaccinationsResponse struct {
+	Vaccinations []vaccinationResponse `json:"vaccinations"`
+}
+
+type vaccinationResponse struct {
+	Name             lenientString `json:"name"`
+	Product          lenientString `json:"product"`
+	LotNumber        lenientString `json:"lot_number"`
+	AdministeredDate lenientString `json:"administered_date" description:"yyyy-MM-dd, empty if the record doesn't say"`
+	NextDueDate      lenientString `json:"next_due_date" description:"yyyy-MM-dd, only if the record states when the next dose is due"`
+	StartLine        lenientInt    `json:"start_line" description:"first line that mentions the vaccination"`
+	EndLine          lenientInt    `json:"end_line" description:"last line that mentions the vaccination"`
+}
+
+var vaccinationsSchema = &ResponseSchema{
+	Name:   "vaccinations",
+	Schema: utils.JSONSchemaFor(vaccinationsResponse{}),
+}
+
+// vaccineKeywords map the ways records name a vaccine to the key schedules use. Checked in
+// order: "FVRCP (feline distemper)" must be fvrcp before the canine "distemper" can match it,
+// and "DA2PP (Distemper, Adenovirus, Parainfluenza, Parvovirus)" must be da2pp before
+// "influenza" can.
+var vaccineKeywords = []struct {
+	vaccine  string
+	keywords []string
+}{
+	{"fvrcp", []string{"fvrcp", "feline distemper", "rhinotracheitis", "panleukopenia", "calicivirus"}},
+	{"felv", []string{"felv", "feline leukemia"}},
+	{"da2pp", []string{"da2pp", "dhpp", "dapp", "dappv", "distemper"}},
+	{"rabies", []string{"rabies"}},
+	{"leptospirosis", []string{"lepto"}},
+	{"bordetella", []string{"bordetella", "kennel cough"}},
+	{"canine_influenza", []string{"influenza", "civ"}},
+	{"lyme", []string{"lyme", "borrelia"}},
+}
+
+var nonKeyCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NormalizeVaccine returns the schedule key for a vaccine name, or a slug of the name for vaccines no schedule knows
+func NormalizeVaccine(name string) string {
+	lower := strings.ToLower(name)
+	for _, entry := range vaccineKeywords {
+		for _, keyword := range entry.keywords {
+			if strings.Contains(lower, keyword) {
+				return entry.vaccine
+			}
+		}
+	}
+	return strings.Trim(nonKeyCharacters.ReplaceAllString(lower, "_"), "_")
+}
+
+// ExtractVaccinations asks the AI service which vaccines a saved document says were given and
+// stores them for the document's patient, replacing what an earlier extraction found. A
+// vaccination without its own date is dated by the document's service date.
+func ExtractVaccinations(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	var decoded vaccinationsResponse
+	inferenceID, err := queryDocument(ctx, aiService, document, prompts.VaccinationExtractionPrompt, vaccinationsSchema, model, &decoded)
+	if err != nil {
+		return err
+	}
+
+	var vaccinations []models.Vaccination
+	for _, item := range decoded.Vaccinations {
+		name := strings.TrimSpace(string(item.Name))
+		if name == "" {
+			continue
+		}
+
+		vaccination := models.Vaccination{
+			PatientID:          document.PatientID,
+			AnalyzedDocumentID: document.ID,
+			AnalysisRunID:      document.AnalysisRunID,
+			Vaccine:            NormalizeVaccine(name),
+			Name:               name,
+			Product:            optionalString(item.Product),
+			LotNumber:          optionalString(item.LotNumber),
+			AdministeredOn:     parseExtractedDate(item.AdministeredDate),
+			NextDueOn:          parseExtractedDate(item.NextDueDate),
+			InferenceID:        inferenceID,
+		}
+		if vaccination.AdministeredOn == nil {
+			vaccination.AdministeredOn = document.ServiceDate
+		}
+		vaccination.StartLine, vaccination.EndLine, vaccination.SourceText = documentSourceLines(document, int64(item.StartLine), int64(item.EndLine))
+
+		vaccinations = append(vaccinations, vaccination)
+	}
+
+	return repository.ReplaceDocumentVaccinations(document.ID, vaccinations)
+}
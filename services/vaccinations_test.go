@@ -0,0 +1,33 @@
+package services
+
+import "testing"
+
+func TestNormalizeVaccine(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Rabies (3-year)", want: "rabies"},
+		{name: "DA2PP (Distemper, Adenovirus, Parainfluenza, Parvovirus)", want: "da2pp"},
+		{name: "DHPP", want: "da2pp"},
+		{name: "Canine Distemper", want: "da2pp"},
+		{name: "FVRCP (feline distemper)", want: "fvrcp"},
+		{name: "Feline Distemper", want: "fvrcp"},
+		{name: "Feline Panleukopenia", want: "fvrcp"},
+		{name: "FeLV", want: "felv"},
+		{name: "Feline Leukemia Virus", want: "felv"},
+		{name: "Leptospirosis 4-way", want: "leptospirosis"},
+		{name: "Bordetella (Kennel Cough)", want: "bordetella"},
+		{name: "Canine Influenza H3N2/H3N8", want: "canine_influenza"},
+		{name: "Lyme (Borrelia burgdorferi)", want: "lyme"},
+		{name: "Rattlesnake Vaccine", want: "rattlesnake_vaccine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeVaccine(tt.name); got != tt.want {
+				t.Errorf("NormalizeVaccine(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"PennieAI/models"
+	"PennieAI/repository"
+)
+
+// vitalPatterns read "Label: <number> <unit>" lines. The number is required, so lines like
+// "Weight Monitoring: Continue regular weight checks" don't match.
+var vitalPatterns = []struct {
+	vital   string
+	pattern *regexp.Regexp
+}{
+	{models.VitalWeight, regexp.MustCompile(`(?i)^(?:body )?weight:\s*(\d+(?:\.\d+)?)\s*(lbs?|pounds?|kgs?|kilograms?|g|grams?|oz|ounces?)?\b`)},
+	{models.VitalTemperature, regexp.MustCompile(`(?i)^(?:temperature|temp|rectal temperature):\s*(\d+(?:\.\d+)?)\s*(°\s*[fc]|[fc]\b|degrees? [fc]\w*)?`)},
+	{models.VitalHeartRate, regexp.MustCompile(`(?i)^(?:heart rate|pulse|hr):\s*(\d+(?:\.\d+)?)\s*(bpm|beats/min|beats per minute)?`)},
+	{models.VitalRespiratoryRate, regexp.MustCompile(`(?i)^(?:respiratory rate|respiration rate|respirations|rr):\s*(\d+(?:\.\d+)?)\s*(breaths/min|breaths per minute|brpm|bpm|rpm)?`)},
+}
+
+/*
+ParseVitals reads the vital signs in a document's lines (numbered from startLine) and
+normalizes them to models.VitalUnits. A weight without a unit can't be normalized and is
+skipped; a temperature without one is Fahrenheit above 50, since no live animal is 50°C.
+*/
+func ParseVitals(documentLines []string, startLine int64) []models.VitalObservation {
+	var observations []models.VitalObservation
+
+	for i, line := range documentLines {
+		trimmed := strings.TrimSpace(line)
+		for _, vitalPattern := range vitalPatterns {
+			match := vitalPattern.pattern.FindStringSubmatch(trimmed)
+			if match == nil {
+				continue
+			}
+
+			originalValue, err := strconv.ParseFloat(match[1], 64)
+			if err != nil {
+				continue
+			}
+			originalUnit := strings.TrimSpace(match[2])
+
+			value, ok := normalizeVital(vitalPattern.vital, originalValue, originalUnit)
+			if !ok {
+				continue
+			}
+
+			observations = append(observations, models.VitalObservation{
+				Vital:         vitalPattern.vital,
+				Value:         math.Round(value*100) / 100,
+				Unit:          models.VitalUnits[vitalPattern.vital],
+				OriginalValue: originalValue,
+				OriginalUnit:  originalUnit,
+				OriginalText:  trimmed,
+				LineNumber:    startLine + int64(i),
+			})
+			break
+		}
+	}
+
+	return observations
+}
+
+// normalizeVital converts a reading to the vital's unit in models.VitalUnits
+func normalizeVital(vital string, value float64, unit string) (float64, bool) {
+	unit = strings.ToLower(strings.ReplaceAll(unit, " ", ""))
+
+	switch vital {
+	case models.VitalWeight:
+		switch {
+		case strings.HasPrefix(unit, "lb"), strings.HasPrefix(unit, "pound"):
+			return value * 0.45359237, true
+		case strings.HasPrefix(unit, "kg"), strings.HasPrefix(unit, "kilogram"):
+			return value, true
+		case unit == "g", strings.HasPrefix(unit, "gram"):
+			return value / 1000, true
+		case strings.HasPrefix(unit, "oz"), strings.HasPrefix(unit, "ounce"):
+			return value * 0.028349523125, true
+		}
+		return 0, false
+	case models.VitalTemperature:
+		fahrenheit := strings.Contains(unit, "f") || (unit == "" && value > 50)
+		if fahrenheit {
+			return (value - 32) * 5 / 9, true
+		}
+		return value, true
+	}
+
+	// Rates are already per minute
+	return value, true
+}
+
+// ExtractVitals reads a saved document's vital signs without calling the AI service and stores
+// them for the document's patient, dated by the document's service date
+func ExtractVitals(ctx context.Context, aiService *AIService, document *models.AnalyzedDocument, model string) error {
+	if document.PatientID == 0 {
+		return errors.New("document isn't linked to a patient")
+	}
+
+	observations := ParseVitals(document.WindowLines, document.StartLine)
+	for i := range observations {
+		observations[i].PatientID = document.PatientID
+		observations[i].AnalyzedDocumentID = document.ID
+		observations[i].AnalysisRunID = document.AnalysisRunID
+		observations[i].ObservedOn = document.ServiceDate
+	}
+
+	return repository.ReplaceDocumentVitals(document, observations)
+}
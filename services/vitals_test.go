@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"PennieAI/models"
+)
+
+func TestParseVitals(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantVital string
+		wantValue float64
+		wantUnit  string // The unit as written
+	}{
+		{line: "Weight: 65 lbs", wantVital: models.VitalWeight, wantValue: 29.48, wantUnit: "lbs"},
+		{line: "Body Weight: 29.5 kg", wantVital: models.VitalWeight, wantValue: 29.5, wantUnit: "kg"},
+		{line: "Weight: 450 g", wantVital: models.VitalWeight, wantValue: 0.45, wantUnit: "g"},
+		{line: "Weight: 12 oz", wantVital: models.VitalWeight, wantValue: 0.34, wantUnit: "oz"},
+		{line: "Temperature: 101.5°F", wantVital: models.VitalTemperature, wantValue: 38.61, wantUnit: "°F"},
+		{line: "Temp: 38.5 C", wantVital: models.VitalTemperature, wantValue: 38.5, wantUnit: "C"},
+		{line: "Rectal Temperature: 102 degrees Fahrenheit", wantVital: models.VitalTemperature, wantValue: 38.89, wantUnit: "degrees Fahrenheit"},
+		{line: "Temperature: 101.5", wantVital: models.VitalTemperature, wantValue: 38.61},
+		{line: "Temperature: 38.2", wantVital: models.VitalTemperature, wantValue: 38.2},
+		{line: "  Heart Rate: 90 bpm", wantVital: models.VitalHeartRate, wantValue: 90, wantUnit: "bpm"},
+		{line: "Respiratory Rate: 24 breaths/min", wantVital: models.VitalRespiratoryRate, wantValue: 24, wantUnit: "breaths/min"},
+		// Not readings
+		{line: "Weight: 65"},
+		{line: "Weight Monitoring: Continue regular weight checks"},
+		{line: "Temperament: friendly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			observations := ParseVitals([]string{"Examination Notes:", tt.line}, 10)
+
+			if tt.wantVital == "" {
+				if len(observations) != 0 {
+					t.Fatalf("got %+v, want no observations", observations)
+				}
+				return
+			}
+			if len(observations) != 1 {
+				t.Fatalf("got %d observations, want 1", len(observations))
+			}
+
+			observation := observations[0]
+			if observation.Vital != tt.wantVital || observation.Value != tt.wantValue || observation.Unit != models.VitalUnits[tt.wantVital] {
+				t.Errorf("got %s %v %s, want %s %v %s", observation.Vital, observation.Value, observation.Unit, tt.wantVital, tt.wantValue, models.VitalUnits[tt.wantVital])
+			}
+			if observation.OriginalUnit != tt.wantUnit {
+				t.Errorf("original unit = %q, want %q", observation.OriginalUnit, tt.wantUnit)
+			}
+			if observation.LineNumber != 11 {
+				t.Errorf("line number = %d, want 11", observation.LineNumber)
+			}
+		})
+	}
+}
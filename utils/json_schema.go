@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaer lets a type describe its own schema, e.g. a string type that also decodes numbers
+type JSONSchemaer interface {
+	JSONSchema() map[string]interface{}
+}
+
+var jsonSchemaerType = reflect.TypeOf((*JSONSchemaer)(nil)).Elem()
+
+/*
+JSONSchemaFor builds a JSON Schema for v's type, in the subset OpenAI structured outputs accept
+in strict mode: every property is required and objects don't allow additional properties, so
+optional values are pointers, which become nullable. Property names come from the json tags and
+a `description` tag is copied into the property's schema. An `enum` tag (comma separated)
+limits a property to those values.
+*/
+func JSONSchemaFor(v interface{}) map[string]interface{} {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+func schemaForType(t reflect.Type) map[string]interface{} {
+	// Called on a new value, not the zero one, so a nil pointer never reaches a value receiver.
+	// Pointers fall through and wrap their element's schema in nullable.
+	if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(jsonSchemaerType) {
+		return reflect.New(t).Interface().(JSONSchemaer).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaForType(t.Elem()))
+	case reflect.Struct:
+		return schemaForStruct(t)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+
+	// Maps and interfaces can't be described in strict mode, leave them unconstrained
+	return map[string]interface{}{}
+}
+
+func schemaForStruct(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		property := schemaForType(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+
+		properties[name] = property
+		required = append(required, name)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// nullable allows null as well as the schema's own type
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if schemaType, ok := schema["type"].(string); ok && schemaType != "object" && schemaType != "array" {
+		schema["type"] = []string{schemaType, "null"}
+		return schema
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+type flexibleString string
+
+func (flexibleString) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": []string{"string", "number"}}
+}
+
+type schemaLine struct {
+	Number int `json:"number"`
+}
+
+type schemaDocument struct {
+	Title     string          `json:"title" description:"Document title"`
+	Kind      string          `json:"kind" enum:"email,lab"`
+	Score     float64         `json:"score"`
+	Done      bool            `json:"done"`
+	Count     *int            `json:"count"`
+	Note      *flexibleString `json:"note"`
+	Lines     []schemaLine    `json:"lines"`
+	Parent    *schemaLine     `json:"parent,omitempty"`
+	NoTag     string
+	Skipped   string `json:"-"`
+	unexposed string
+}
+
+func TestJSONSchemaFor(t *testing.T) {
+	lineSchema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"number": map[string]interface{}{"type": "integer"}},
+		"required":             []string{"number"},
+		"additionalProperties": false,
+	}
+
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":  map[string]interface{}{"type": "string", "description": "Document title"},
+			"kind":   map[string]interface{}{"type": "string", "enum": []string{"email", "lab"}},
+			"score":  map[string]interface{}{"type": "number"},
+			"done":   map[string]interface{}{"type": "boolean"},
+			"count":  map[string]interface{}{"type": []string{"integer", "null"}},
+			"note":   map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": []string{"string", "number"}}, map[string]interface{}{"type": "null"}}},
+			"lines":  map[string]interface{}{"type": "array", "items": lineSchema},
+			"parent": map[string]interface{}{"anyOf": []interface{}{lineSchema, map[string]interface{}{"type": "null"}}},
+			"NoTag":  map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"title", "kind", "score", "done", "count", "note", "lines", "parent", "NoTag"},
+		"additionalProperties": false,
+	}
+
+	if got := JSONSchemaFor(schemaDocument{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONSchemaFor() =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import "strings"
+
+// How good a place between two lines is for a window edge
+const (
+	noBreak     = 0
+	blankBreak  = 1 // The line before is blank
+	headerBreak = 2 // The line after starts a document
+)
+
+/*
+snappedWindows builds windows like the line or token budget modes do, but moves each window
+edge by up to SnapTolerance lines so it lands just before a document header or just after a
+blank line. A document that starts near a window's end is then left out whole instead of
+being cut in half, and the next window starts at its header.
+
+Ends only move down in token budget mode, so a snapped window never goes over the budget.
+Starts never move past the previous window's end, so no line is skipped.
+*/
+func snappedWindows(lines []string, startIndex int, opts *WindowOptions) []Window {
+	headers := make(map[int]bool, len(opts.HeaderLines))
+	for _, index := range opts.HeaderLines {
+		headers[index] = true
+	}
+	tolerance := opts.SnapTolerance
+
+	var windows []Window
+
+	for startIndex < len(lines) {
+		var endIndex, overlap int
+		if opts.TokenBudget > 0 {
+			window := TokenBudgetWindow(lines, startIndex, opts)
+			endIndex = window.StartIndex + len(window.WindowLines)
+			if endIndex < len(lines) {
+				endIndex = snapToBreak(lines, headers, endIndex, max(startIndex+1, endIndex-tolerance), endIndex)
+			}
+			overlap = (endIndex - startIndex) * opts.OverlapSize / opts.WindowSize
+		} else {
+			endIndex = min(startIndex+opts.WindowSize, len(lines))
+			if endIndex < len(lines) {
+				endIndex = snapToBreak(lines, headers, endIndex, max(startIndex+1, endIndex-tolerance), min(len(lines), endIndex+tolerance))
+			}
+			overlap = opts.OverlapSize
+		}
+
+		windows = append(windows, Window{
+			StartIndex:  startIndex,
+			WindowLines: lines[startIndex:endIndex],
+		})
+
+		if endIndex >= len(lines) {
+			break
+		}
+
+		nextStart := endIndex - overlap
+		startIndex = snapToBreak(lines, headers, nextStart, max(startIndex+1, nextStart-tolerance), min(endIndex, nextStart+tolerance))
+	}
+
+	return windows
+}
+
+// snapToBreak returns the best edge between from and to (inclusive), closest to target on a tie, or target if none is a break
+func snapToBreak(lines []string, headers map[int]bool, target int, from int, to int) int {
+	target = min(max(target, from), to)
+
+	best := target
+	bestScore := breakScore(lines, headers, target)
+	for index := from; index <= to; index++ {
+		score := breakScore(lines, headers, index)
+		if score > bestScore || (score == bestScore && score > noBreak && distance(index, target) < distance(best, target)) {
+			best = index
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// breakScore rates the edge between lines[index-1] and lines[index]
+func breakScore(lines []string, headers map[int]bool, index int) int {
+	if headers[index] {
+		return headerBreak
+	}
+	if index > 0 && index <= len(lines) && strings.TrimSpace(lines[index-1]) == "" {
+		return blankBreak
+	}
+	return noBreak
+}
+
+func distance(a int, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -1,5 +1,20 @@
 package utils
 
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	DefaultWindowSize  = 300
+	DefaultOverlapSize = 100
+
+	// OpenAI averages about 4 characters per token on English prose, but numbers and
+	// abbreviations in lab tables tokenize worse, so estimates assume 3 to stay under budget
+	charsPerToken = 3
+)
+
 type Window struct {
 	StartIndex  int
 	WindowLines []string
@@ -9,21 +24,73 @@ type Window struct {
 type WindowOptions struct {
 	WindowSize  int
 	OverlapSize int
+	// TokenBudget switches to token budget mode: each window takes as many lines as fit in
+	// TokenBudget - ReservedTokens estimated tokens instead of WindowSize lines, and overlaps
+	// the next window by the same OverlapSize/WindowSize share of its lines
+	TokenBudget    int
+	ReservedTokens int // Tokens the rest of the prompt needs, only used in token budget mode
+	// SnapTolerance lets each window edge move up to this many lines to land on a header or
+	// blank line, see snapToBreak. 0 keeps the exact offsets.
+	SnapTolerance int
+	HeaderLines   []int // 0-based indexes of lines that start a document, preferred over blank lines when snapping
+}
+
+// Validate rejects options that would never advance through the file
+func (o WindowOptions) Validate() error {
+	if o.WindowSize <= 0 {
+		return errors.New("window size must be positive")
+	}
+	// A larger overlap would query most lines more than twice
+	if o.OverlapSize < 0 || o.OverlapSize > o.WindowSize/2 {
+		return errors.New("overlap size must be at least 0 and at most half the window size")
+	}
+	if o.TokenBudget < 0 {
+		return errors.New("token budget must be at least 0")
+	}
+	if o.SnapTolerance < 0 || (o.TokenBudget == 0 && o.SnapTolerance >= o.WindowSize-o.OverlapSize) {
+		return errors.New("snap tolerance must be at least 0 and smaller than the window size minus the overlap")
+	}
+	if o.TokenBudget > 0 && o.ReservedTokens >= o.TokenBudget {
+		return fmt.Errorf("the prompt needs about %d tokens before any document lines, which leaves nothing of the %d token budget", o.ReservedTokens, o.TokenBudget)
+	}
+	return nil
+}
+
+// EstimateTokens approximates how many tokens text will cost without calling a tokenizer
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateLineTokens is the cost of a line the way prompts number it, "<line number>: <line>\n"
+func EstimateLineTokens(lineIndex int, line string) int {
+	return EstimateTokens(fmt.Sprintf("%d: %s\n", lineIndex+1, line))
 }
 
 func WindowBuilder(lines []string, opts *WindowOptions) []Window {
+	return WindowBuilderFrom(lines, 0, opts)
+}
+
+// WindowBuilderFrom builds the windows covering lines[startIndex:], keeping StartIndex relative to lines
+func WindowBuilderFrom(lines []string, startIndex int, opts *WindowOptions) []Window {
 	if opts == nil {
 		opts = &WindowOptions{
-			WindowSize:  300,
-			OverlapSize: 100,
+			WindowSize:  DefaultWindowSize,
+			OverlapSize: DefaultOverlapSize,
 		}
 	}
 
+	if opts.SnapTolerance > 0 {
+		return snappedWindows(lines, startIndex, opts)
+	}
+	if opts.TokenBudget > 0 {
+		return tokenBudgetWindows(lines, startIndex, opts)
+	}
+
 	stepSize := opts.WindowSize - opts.OverlapSize
 
 	var windows []Window
 
-	for startIndex := 0; startIndex < len(lines); startIndex += stepSize {
+	for ; startIndex < len(lines); startIndex += stepSize {
 
 		endIndex := startIndex + opts.WindowSize
 		if endIndex > len(lines) {
@@ -43,3 +110,48 @@ func WindowBuilder(lines []string, opts *WindowOptions) []Window {
 	return windows
 
 }
+
+func tokenBudgetWindows(lines []string, startIndex int, opts *WindowOptions) []Window {
+	var windows []Window
+
+	for startIndex < len(lines) {
+		window := TokenBudgetWindow(lines, startIndex, opts)
+		windows = append(windows, window)
+
+		endIndex := window.StartIndex + len(window.WindowLines)
+		if endIndex >= len(lines) {
+			break
+		}
+
+		overlap := len(window.WindowLines) * opts.OverlapSize / opts.WindowSize
+		// Always move forward, even if a single line filled the whole window
+		startIndex = max(endIndex-overlap, startIndex+1)
+	}
+
+	return windows
+}
+
+/*
+TokenBudgetWindow takes lines from startIndex until the next one would push the estimate past
+TokenBudget - ReservedTokens. A window always gets at least one line, so a single line longer
+than the budget still ends up in a (too large) window instead of stalling the analysis.
+*/
+func TokenBudgetWindow(lines []string, startIndex int, opts *WindowOptions) Window {
+	available := opts.TokenBudget - opts.ReservedTokens
+
+	endIndex := startIndex
+	used := 0
+	for endIndex < len(lines) {
+		lineTokens := EstimateLineTokens(endIndex, lines[endIndex])
+		if used+lineTokens > available && endIndex > startIndex {
+			break
+		}
+		used += lineTokens
+		endIndex++
+	}
+
+	return Window{
+		StartIndex:  startIndex,
+		WindowLines: lines[startIndex:endIndex],
+	}
+}
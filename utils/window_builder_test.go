@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLines has a blank line every 37 lines and lines of varying length, so snapping and token budgets have something to work with
+func testLines(count int) []string {
+	lines := make([]string, count)
+	for i := range lines {
+		if i%37 == 36 {
+			continue
+		}
+		lines[i] = fmt.Sprintf("line %d %s", i+1, strings.Repeat("x", i%50))
+	}
+	return lines
+}
+
+// buildWindows fails the test if the builder doesn't return in time, instead of hanging until the test timeout
+func buildWindows(t *testing.T, lines []string, opts *WindowOptions) []Window {
+	t.Helper()
+
+	done := make(chan []Window, 1)
+	go func() {
+		done <- WindowBuilder(lines, opts)
+	}()
+
+	select {
+	case windows := <-done:
+		return windows
+	case <-time.After(5 * time.Second):
+		t.Fatalf("WindowBuilder didn't terminate for %+v", *opts)
+		return nil
+	}
+}
+
+// checkCoverage fails unless the windows cover every line in order, each starting after the
+// previous one and no later than where it ended
+func checkCoverage(t *testing.T, lines []string, windows []Window) {
+	t.Helper()
+
+	if len(lines) == 0 {
+		if len(windows) != 0 {
+			t.Errorf("got %d windows for no lines", len(windows))
+		}
+		return
+	}
+	if len(windows) == 0 {
+		t.Fatal("got no windows")
+	}
+	if windows[0].StartIndex != 0 {
+		t.Errorf("first window starts at %d, want 0", windows[0].StartIndex)
+	}
+
+	coveredTo := 0
+	for i, window := range windows {
+		if len(window.WindowLines) == 0 {
+			t.Fatalf("window %d is empty", i)
+		}
+		if i > 0 && window.StartIndex <= windows[i-1].StartIndex {
+			t.Fatalf("window %d starts at %d, not after window %d at %d", i, window.StartIndex, i-1, windows[i-1].StartIndex)
+		}
+		if window.StartIndex > coveredTo {
+			t.Fatalf("window %d starts at %d, skipping lines %d-%d", i, window.StartIndex, coveredTo, window.StartIndex-1)
+		}
+		for j, line := range window.WindowLines {
+			if line != lines[window.StartIndex+j] {
+				t.Fatalf("window %d line %d doesn't match line %d of the file", i, j, window.StartIndex+j)
+			}
+		}
+		coveredTo = window.StartIndex + len(window.WindowLines)
+	}
+	if coveredTo != len(lines) {
+		t.Errorf("windows end at %d, want %d", coveredTo, len(lines))
+	}
+}
+
+func TestWindowBuilderCoversEveryLine(t *testing.T) {
+	lines := testLines(1000)
+
+	var headerLines []int
+	for i := 0; i < len(lines); i += 45 {
+		headerLines = append(headerLines, i)
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		opts  WindowOptions
+	}{
+		{name: "default sizes", lines: lines, opts: WindowOptions{WindowSize: DefaultWindowSize, OverlapSize: DefaultOverlapSize}},
+		{name: "no overlap", lines: lines, opts: WindowOptions{WindowSize: 64}},
+		{name: "window larger than the file", lines: lines[:20], opts: WindowOptions{WindowSize: 300, OverlapSize: 100}},
+		{name: "no lines", lines: nil, opts: WindowOptions{WindowSize: 300, OverlapSize: 100}},
+		{name: "snap to blank lines", lines: lines, opts: WindowOptions{WindowSize: 100, OverlapSize: 30, SnapTolerance: 20}},
+		{name: "snap to headers", lines: lines, opts: WindowOptions{WindowSize: 100, OverlapSize: 50, SnapTolerance: 49, HeaderLines: headerLines}},
+		{name: "token budget", lines: lines, opts: WindowOptions{WindowSize: 300, OverlapSize: 100, TokenBudget: 2000, ReservedTokens: 500}},
+		{name: "token budget without overlap", lines: lines, opts: WindowOptions{WindowSize: 300, TokenBudget: 800}},
+		{name: "token budget with snapping", lines: lines, opts: WindowOptions{WindowSize: 300, OverlapSize: 100, TokenBudget: 2000, ReservedTokens: 500, SnapTolerance: 30, HeaderLines: headerLines}},
+		{
+			name:  "token budget smaller than a line",
+			lines: []string{"short", strings.Repeat("long ", 500), "short", strings.Repeat("long ", 500)},
+			opts:  WindowOptions{WindowSize: 300, OverlapSize: 100, TokenBudget: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); err != nil {
+				t.Fatalf("options are invalid: %v", err)
+			}
+
+			windows := buildWindows(t, tt.lines, &tt.opts)
+			checkCoverage(t, tt.lines, windows)
+
+			if tt.opts.TokenBudget == 0 || tt.opts.SnapTolerance > 0 {
+				return
+			}
+			available := tt.opts.TokenBudget - tt.opts.ReservedTokens
+			for i, window := range windows {
+				if len(window.WindowLines) == 1 {
+					// A line over the budget still gets a window of its own
+					continue
+				}
+				tokens := 0
+				for j, line := range window.WindowLines {
+					tokens += EstimateLineTokens(window.StartIndex+j, line)
+				}
+				if tokens > available {
+					t.Errorf("window %d is estimated at %d tokens, over the %d available", i, tokens, available)
+				}
+			}
+		})
+	}
+}
+
+func TestWindowOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    WindowOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: WindowOptions{WindowSize: DefaultWindowSize, OverlapSize: DefaultOverlapSize}},
+		{name: "overlap of half the window", opts: WindowOptions{WindowSize: 100, OverlapSize: 50}},
+		{name: "no window size", opts: WindowOptions{}, wantErr: true},
+		{name: "overlap over half the window", opts: WindowOptions{WindowSize: 100, OverlapSize: 51}, wantErr: true},
+		{name: "overlap as large as the window", opts: WindowOptions{WindowSize: 100, OverlapSize: 100}, wantErr: true},
+		{name: "negative overlap", opts: WindowOptions{WindowSize: 100, OverlapSize: -1}, wantErr: true},
+		{name: "snap tolerance reaching the next window", opts: WindowOptions{WindowSize: 100, OverlapSize: 50, SnapTolerance: 50}, wantErr: true},
+		{name: "reserved tokens filling the budget", opts: WindowOptions{WindowSize: 100, TokenBudget: 500, ReservedTokens: 500}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}